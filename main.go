@@ -2,16 +2,43 @@ package main
 
 import (
 	"devbook/src/config"
+	"devbook/src/logging"
+	"devbook/src/publishing"
 	"devbook/src/router"
+	"devbook/src/timeline"
+	"devbook/src/trending"
+	"devbook/src/webhooks"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 )
 
 func main() {
 	config.Load()
+
+	if err := logging.Setup(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := webhooks.Start(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := publishing.Start(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := timeline.Start(); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := trending.Start(); err != nil {
+		log.Fatal(err)
+	}
+
 	r := router.Generate()
 
-	fmt.Printf("Listen on port %d", config.Port)
+	slog.Info("listening", "port", config.Port)
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.Port), r))
 }
@@ -1,7 +1,28 @@
 CREATE DATABASE IF NOT EXISTS devbook;
 USE devbook;
 
-DROP TABLE IF EXISTS post;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS notification_settings;
+DROP TABLE IF EXISTS notification_actors;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS post_mentions;
+DROP TABLE IF EXISTS post_tags;
+DROP TABLE IF EXISTS post_likes;
+DROP TABLE IF EXISTS media;
+DROP TABLE IF EXISTS post_revisions;
+DROP TABLE IF EXISTS reposts;
+DROP TABLE IF EXISTS bookmarks;
+DROP TABLE IF EXISTS pinned_posts;
+DROP TABLE IF EXISTS poll_votes;
+DROP TABLE IF EXISTS poll_ballots;
+DROP TABLE IF EXISTS poll_options;
+DROP TABLE IF EXISTS polls;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS mutes;
+DROP TABLE IF EXISTS dismissed_suggestions;
+DROP TABLE IF EXISTS blocks;
+DROP TABLE IF EXISTS follow_requests;
 DROP TABLE IF EXISTS followers;
 DROP TABLE IF EXISTS users;
 
@@ -12,6 +33,14 @@ CREATE TABLE users(
     nick varchar(50) not null unique,
     email varchar(50) not null unique,
     password varchar(100) not null unique,
+    admin boolean not null default false,
+    private boolean not null default false,
+    bio varchar(160) not null default '',
+    website varchar(500) not null default '',
+    location varchar(50) not null default '',
+    avatar_url varchar(500) not null default '',
+    banner_url varchar(500) not null default '',
+    version int not null default 1,
     created_at timestamp default current_timestamp()
 ) ENGINE=INNODB;
 
@@ -29,16 +58,379 @@ CREATE TABLE followers(
     primary key(user_id, follower_id)
 ) ENGINE=INNODB;
 
+CREATE TABLE follow_requests(
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    requester_id int not null,
+    FOREIGN KEY (requester_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp(),
+
+    primary key(user_id, requester_id)
+) ENGINE=INNODB;
+
+CREATE TABLE blocks(
+    blocker_id int not null,
+    FOREIGN KEY (blocker_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    blocked_id int not null,
+    FOREIGN KEY (blocked_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp(),
+
+    primary key(blocker_id, blocked_id)
+) ENGINE=INNODB;
+
+CREATE TABLE mutes(
+    muter_id int not null,
+    FOREIGN KEY (muter_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    muted_id int not null,
+    FOREIGN KEY (muted_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp(),
+
+    primary key(muter_id, muted_id)
+) ENGINE=INNODB;
+
+CREATE TABLE dismissed_suggestions(
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    suggested_id int not null,
+    FOREIGN KEY (suggested_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp(),
+
+    primary key(user_id, suggested_id)
+) ENGINE=INNODB;
+
 CREATE TABLE posts(
     id int auto_increment primary key,
     title varchar(50) not null,
     content varchar(300) not null unique,
     likes int default 0,
+    visibility enum('public', 'followers', 'only-me') not null default 'public',
+    status enum('draft', 'scheduled', 'published') not null default 'published',
+    publish_at timestamp null default null,
+    version int not null default 1,
+    revision_count int not null default 1,
+    edited_at timestamp null default null,
+    quote_of_id int null default null,
 
     author_id int not null,
     FOREIGN KEY (author_id)
     REFERENCES users(id)
     ON DELETE CASCADE,
 
+    created_at timestamp default current_timestamp(),
+
+    index(status, publish_at)
+) ENGINE=INNODB;
+
+CREATE TABLE reposts(
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp(),
+
+    primary key(user_id, post_id),
+    index(post_id),
+    index(user_id, created_at)
+) ENGINE=INNODB;
+
+CREATE TABLE polls(
+    post_id int primary key,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    closes_at timestamp not null,
+    multiple boolean not null default false,
+    hide_results boolean not null default false
+) ENGINE=INNODB;
+
+CREATE TABLE poll_options(
+    id int auto_increment primary key,
+
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES polls(post_id)
+    ON DELETE CASCADE,
+
+    position int not null,
+    label varchar(50) not null,
+
+    unique(post_id, position)
+) ENGINE=INNODB;
+
+CREATE TABLE poll_ballots(
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES polls(post_id)
+    ON DELETE CASCADE,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp(),
+
+    primary key(post_id, user_id)
+) ENGINE=INNODB;
+
+CREATE TABLE poll_votes(
+    post_id int not null,
+    user_id int not null,
+    FOREIGN KEY (post_id, user_id)
+    REFERENCES poll_ballots(post_id, user_id)
+    ON DELETE CASCADE,
+
+    option_id int not null,
+    FOREIGN KEY (option_id)
+    REFERENCES poll_options(id)
+    ON DELETE CASCADE,
+
+    primary key(post_id, user_id, option_id),
+    index(option_id)
+) ENGINE=INNODB;
+
+CREATE TABLE pinned_posts(
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    pinned_at timestamp(6) default current_timestamp(6),
+
+    primary key(user_id, post_id)
+) ENGINE=INNODB;
+
+CREATE TABLE bookmarks(
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp(),
+
+    primary key(user_id, post_id),
+    index(user_id, created_at)
+) ENGINE=INNODB;
+
+CREATE TABLE post_revisions(
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    revision int not null,
+    title varchar(50) not null,
+    content varchar(300) not null,
+    created_at timestamp default current_timestamp(),
+
+    primary key(post_id, revision)
+) ENGINE=INNODB;
+
+CREATE TABLE media(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    post_id int,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    kind enum('image', 'file') not null,
+    content_type varchar(100) not null,
+    size int not null,
+    width int not null default 0,
+    height int not null default 0,
+    storage_key varchar(200) not null,
+    thumbnail_key varchar(200) not null default '',
+    position int not null default 0,
+    created_at timestamp default current_timestamp(),
+
+    index(post_id, position)
+) ENGINE=INNODB;
+
+CREATE TABLE post_mentions(
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    position int not null,
+    length int not null,
+
+    primary key(post_id, position)
+) ENGINE=INNODB;
+
+CREATE TABLE post_tags(
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    tag varchar(50) not null,
+
+    primary key(post_id, tag),
+    index(tag)
+) ENGINE=INNODB;
+
+CREATE TABLE post_likes(
+    id int auto_increment primary key,
+
+    post_id int not null,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    created_at timestamp default current_timestamp(),
+
+    index(created_at),
+    unique(post_id, user_id)
+) ENGINE=INNODB;
+
+CREATE TABLE notifications(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    type varchar(20) not null,
+
+    post_id int,
+    FOREIGN KEY (post_id)
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    group_key varchar(100) not null,
+
+    actor_id int not null,
+    FOREIGN KEY (actor_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    actor_count int not null default 1,
+    read_at timestamp null default null,
+    created_at timestamp default current_timestamp(),
+    updated_at timestamp default current_timestamp(),
+
+    unread_group_key varchar(100) AS (IF(read_at IS NULL, group_key, NULL)) STORED,
+
+    INDEX (user_id, read_at),
+    UNIQUE (user_id, unread_group_key)
+) ENGINE=INNODB;
+
+CREATE TABLE notification_actors(
+    notification_id int not null,
+    FOREIGN KEY (notification_id)
+    REFERENCES notifications(id)
+    ON DELETE CASCADE,
+
+    actor_id int not null,
+    FOREIGN KEY (actor_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    primary key(notification_id, actor_id)
+) ENGINE=INNODB;
+
+CREATE TABLE notification_settings(
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    type varchar(20) not null,
+    enabled boolean not null default true,
+
+    primary key(user_id, type)
+) ENGINE=INNODB;
+
+CREATE TABLE webhooks(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    url varchar(500) not null,
+    secret varchar(64) not null,
+    events varchar(255) not null,
+    global boolean not null default false,
+    active boolean not null default true,
+    failures int not null default 0,
+    created_at timestamp default current_timestamp()
+) ENGINE=INNODB;
+
+CREATE TABLE webhook_deliveries(
+    id int auto_increment primary key,
+
+    webhook_id int not null,
+    FOREIGN KEY (webhook_id)
+    REFERENCES webhooks(id)
+    ON DELETE CASCADE,
+
+    event_id int not null,
+    event varchar(50) not null,
+    payload text not null,
+    attempt int not null,
+    status_code int not null default 0,
+    success boolean not null default false,
+    error varchar(500) not null default '',
     created_at timestamp default current_timestamp()
 ) ENGINE=INNODB;
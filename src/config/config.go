@@ -5,14 +5,41 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 var (
-	StringConnection = ""
-	Port             = 0
-	SecretKey        []byte
+	StringConnection      = ""
+	Port                  = 0
+	SecretKey             []byte
+	WebhookMaxAttempts    = 0
+	WebhookMaxFailures    = 0
+	WebhookTimeout        time.Duration
+	WebhookAllowPrivate   = false
+	WebhookWorkers        = 0
+	WebhookQueueSize      = 0
+	RequireIfMatch        = false
+	MediaStorage          = ""
+	MediaDir              = ""
+	MediaMaxSize          int64
+	MediaMaxPerPost       = 0
+	S3Endpoint            = ""
+	S3Region              = ""
+	S3Bucket              = ""
+	S3AccessKey           = ""
+	S3SecretKey           = ""
+	SchedulerInterval     time.Duration
+	TimelineStore         = ""
+	TimelineSize          = 0
+	FanoutMaxFollowers    = 0
+	RedisAddr             = ""
+	RedisPassword         = ""
+	TrendingInterval      time.Duration
+	TrendingMinAccountAge time.Duration
+	LogLevel              = ""
+	LogFormat             = ""
 )
 
 func Load() {
@@ -34,4 +61,108 @@ func Load() {
 	)
 
 	SecretKey = []byte(os.Getenv("SECRET_KEY"))
+
+	WebhookMaxAttempts, err = strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS"))
+	if err != nil {
+		WebhookMaxAttempts = 5
+	}
+
+	WebhookMaxFailures, err = strconv.Atoi(os.Getenv("WEBHOOK_MAX_FAILURES"))
+	if err != nil {
+		WebhookMaxFailures = 10
+	}
+
+	WebhookTimeout, err = time.ParseDuration(os.Getenv("WEBHOOK_TIMEOUT"))
+	if err != nil {
+		WebhookTimeout = 10 * time.Second
+	}
+
+	WebhookAllowPrivate, _ = strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE"))
+
+	WebhookWorkers, err = strconv.Atoi(os.Getenv("WEBHOOK_WORKERS"))
+	if err != nil || WebhookWorkers <= 0 {
+		WebhookWorkers = 8
+	}
+
+	WebhookQueueSize, err = strconv.Atoi(os.Getenv("WEBHOOK_QUEUE_SIZE"))
+	if err != nil || WebhookQueueSize < 0 {
+		WebhookQueueSize = 1000
+	}
+
+	RequireIfMatch, _ = strconv.ParseBool(os.Getenv("REQUIRE_IF_MATCH"))
+
+	MediaStorage = os.Getenv("MEDIA_STORAGE")
+	if MediaStorage == "" {
+		MediaStorage = "local"
+	}
+
+	MediaDir = os.Getenv("MEDIA_DIR")
+	if MediaDir == "" {
+		MediaDir = "media"
+	}
+
+	MediaMaxSize, err = strconv.ParseInt(os.Getenv("MEDIA_MAX_SIZE"), 10, 64)
+	if err != nil {
+		MediaMaxSize = 10 << 20
+	}
+
+	MediaMaxPerPost, err = strconv.Atoi(os.Getenv("MEDIA_MAX_PER_POST"))
+	if err != nil {
+		MediaMaxPerPost = 4
+	}
+
+	S3Endpoint = os.Getenv("S3_ENDPOINT")
+	S3Region = os.Getenv("S3_REGION")
+	if S3Region == "" {
+		S3Region = "us-east-1"
+	}
+	S3Bucket = os.Getenv("S3_BUCKET")
+	S3AccessKey = os.Getenv("S3_ACCESS_KEY")
+	S3SecretKey = os.Getenv("S3_SECRET_KEY")
+
+	SchedulerInterval, err = time.ParseDuration(os.Getenv("SCHEDULER_INTERVAL"))
+	if err != nil || SchedulerInterval <= 0 {
+		SchedulerInterval = 30 * time.Second
+	}
+
+	TimelineStore = os.Getenv("TIMELINE_STORE")
+	if TimelineStore == "" {
+		TimelineStore = "memory"
+	}
+
+	TimelineSize, err = strconv.Atoi(os.Getenv("TIMELINE_SIZE"))
+	if err != nil || TimelineSize <= 0 {
+		TimelineSize = 800
+	}
+
+	FanoutMaxFollowers, err = strconv.Atoi(os.Getenv("FANOUT_MAX_FOLLOWERS"))
+	if err != nil {
+		FanoutMaxFollowers = 10000
+	}
+
+	RedisAddr = os.Getenv("REDIS_ADDR")
+	if RedisAddr == "" {
+		RedisAddr = "localhost:6379"
+	}
+	RedisPassword = os.Getenv("REDIS_PASSWORD")
+
+	TrendingInterval, err = time.ParseDuration(os.Getenv("TRENDING_INTERVAL"))
+	if err != nil || TrendingInterval <= 0 {
+		TrendingInterval = 5 * time.Minute
+	}
+
+	TrendingMinAccountAge, err = time.ParseDuration(os.Getenv("TRENDING_MIN_ACCOUNT_AGE"))
+	if err != nil {
+		TrendingMinAccountAge = 24 * time.Hour
+	}
+
+	LogLevel = os.Getenv("LOG_LEVEL")
+	if LogLevel == "" {
+		LogLevel = "info"
+	}
+
+	LogFormat = os.Getenv("LOG_FORMAT")
+	if LogFormat == "" {
+		LogFormat = "json"
+	}
 }
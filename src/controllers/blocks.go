@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"devbook/src/auth"
+	"devbook/src/database"
+	"devbook/src/events"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func BlockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, userID, ok := relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	if err = repository.Block(blockerID, userID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	events.Publish(events.UserUnfollowed, events.Follow{UserID: userID, FollowerID: blockerID})
+	events.Publish(events.UserUnfollowed, events.Follow{UserID: blockerID, FollowerID: userID})
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func UnblockUser(w http.ResponseWriter, r *http.Request) {
+	blockerID, userID, ok := relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	if err = repository.Unblock(blockerID, userID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func MuteUser(w http.ResponseWriter, r *http.Request) {
+	muterID, userID, ok := relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	if err = repository.Mute(muterID, userID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func UnmuteUser(w http.ResponseWriter, r *http.Request) {
+	muterID, userID, ok := relationshipTarget(w, r)
+	if !ok {
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	if err = repository.Unmute(muterID, userID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func GetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != tokenUserID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	users, err := repository.GetBlocked(userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, users)
+}
+
+func GetMutedUsers(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != tokenUserID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	users, err := repository.GetMuted(userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, users)
+}
+
+// relationshipTarget returns the caller and the user named in the route,
+// refusing requests where both are the same user.
+func relationshipTarget(w http.ResponseWriter, r *http.Request) (uint64, uint64, bool) {
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return 0, 0, false
+	}
+
+	params := mux.Vars(r)
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return 0, 0, false
+	}
+
+	if tokenUserID == userID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return 0, 0, false
+	}
+
+	return tokenUserID, userID, true
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"database/sql"
+	"devbook/src/auth"
+	"devbook/src/database"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func BookmarkPost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	_, err = repositories.Posts(db).FindOneById(postID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = repositories.Bookmarks(db).Add(tokenUserID, postID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func UnbookmarkPost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	if err = repositories.Bookmarks(db).Remove(tokenUserID, postID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+// GetBookmarks lists a user's saved posts. Bookmarks are private, so only
+// the owner may read them.
+func GetBookmarks(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != tokenUserID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+
+	limit, offset := pagination(r)
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	posts, err := repositories.Bookmarks(db).Find(userID, limit, offset)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err = attachDetails(db, posts, tokenUserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, posts)
+}
+
+func attachBookmarks(db *sql.DB, posts []models.Post, viewerID uint64) error {
+	postIDs := make([]uint64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	bookmarked, err := repositories.Bookmarks(db).FindBookmarked(viewerID, postIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].BookmarkedByMe = bookmarked[posts[i].ID]
+	}
+
+	return nil
+}
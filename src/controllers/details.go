@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"database/sql"
+	"devbook/src/models"
+	"devbook/src/repositories"
+)
+
+// attachDetails fills in what listings and single posts show beyond the
+// post row: mentions, media, whether viewerID bookmarked them, polls and
+// the quoted post as viewerID may see them.
+func attachDetails(db *sql.DB, posts []models.Post, viewerID uint64) error {
+	if err := attachMentions(db, posts); err != nil {
+		return err
+	}
+
+	if err := attachMedia(db, posts); err != nil {
+		return err
+	}
+
+	if err := attachBookmarks(db, posts, viewerID); err != nil {
+		return err
+	}
+
+	if err := attachPolls(db, posts, viewerID); err != nil {
+		return err
+	}
+
+	return attachQuotes(db, posts, viewerID)
+}
+
+// attachQuotes sets the quoted post of every quote, or flags it as
+// unavailable when the original was deleted or is hidden from viewerID.
+func attachQuotes(db *sql.DB, posts []models.Post, viewerID uint64) error {
+	var quotedIDs []uint64
+	for _, post := range posts {
+		if post.QuoteOfID != 0 {
+			quotedIDs = append(quotedIDs, post.QuoteOfID)
+		}
+	}
+
+	if len(quotedIDs) == 0 {
+		return nil
+	}
+
+	found, err := repositories.Posts(db).FindByIDs(uniqueIDs(quotedIDs), viewerID)
+	if err != nil {
+		return err
+	}
+
+	quoted := make([]models.Post, 0, len(found))
+	for _, post := range found {
+		quoted = append(quoted, post)
+	}
+
+	if err = attachMentions(db, quoted); err != nil {
+		return err
+	}
+
+	if err = attachMedia(db, quoted); err != nil {
+		return err
+	}
+
+	byID := make(map[uint64]*models.Post, len(quoted))
+	for i := range quoted {
+		byID[quoted[i].ID] = &quoted[i]
+	}
+
+	for i := range posts {
+		if posts[i].QuoteOfID == 0 {
+			continue
+		}
+
+		if original, ok := byID[posts[i].QuoteOfID]; ok {
+			posts[i].QuotedPost = original
+		} else {
+			posts[i].QuoteUnavailable = true
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"devbook/src/media"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"devbook/src/storage"
+	"errors"
+	"net/http"
+)
+
+// domainErrors maps the errors the lower layers return on purpose to the
+// status and stable code they are reported with.
+var domainErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{repositories.ErrNotFound, http.StatusNotFound, response.CodeNotFound},
+	{repositories.ErrConflict, http.StatusConflict, response.CodeConflict},
+	{models.ErrValidation, http.StatusBadRequest, response.CodeValidation},
+	{repositories.ErrVersionMismatch, http.StatusPreconditionFailed, response.CodeVersionMismatch},
+	{repositories.ErrTooManyPins, http.StatusConflict, response.CodeTooManyPins},
+	{repositories.ErrAlreadyVoted, http.StatusConflict, response.CodeAlreadyVoted},
+	{repositories.ErrPollClosed, http.StatusConflict, response.CodePollClosed},
+	{repositories.ErrMediaUnavailable, http.StatusBadRequest, response.CodeMediaUnavailable},
+	{storage.ErrNotExist, http.StatusNotFound, response.CodeNotFound},
+	{media.ErrUnsupportedType, http.StatusUnsupportedMediaType, response.CodeUnsupportedMediaType},
+}
+
+// writeError answers with the problem matching a domain error, or with a
+// 500 that keeps the details out of the response for anything else.
+// Conflicts name the field holding the duplicate value.
+func writeError(w http.ResponseWriter, err error) {
+	for _, known := range domainErrors {
+		if errors.Is(err, known.err) {
+			problem := response.ProblemFor(w, known.status, known.code, err)
+
+			var conflict *repositories.ConflictError
+			if errors.As(err, &conflict) {
+				problem.Field = conflict.Field
+			}
+
+			response.WriteProblem(w, problem)
+			return
+		}
+	}
+
+	response.Error(w, http.StatusInternalServerError, err)
+}
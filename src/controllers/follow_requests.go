@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"devbook/src/auth"
+	"devbook/src/database"
+	"devbook/src/events"
+	"devbook/src/notifications"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func GetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != tokenUserID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	requests, err := repository.GetFollowRequests(userID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, requests)
+}
+
+func ApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	requesterID, err := strconv.ParseUint(params["requesterId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != tokenUserID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	found, err := repository.ApproveFollowRequest(userID, requesterID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !found {
+		response.Error(w, http.StatusNotFound, errors.New("follow request not found"))
+		return
+	}
+
+	if err = notifications.Follow(db, userID, requesterID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	events.Publish(events.UserFollowed, events.Follow{UserID: userID, FollowerID: requesterID}, userID)
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func RejectFollowRequest(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	requesterID, err := strconv.ParseUint(params["requesterId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != tokenUserID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	found, err := repository.RejectFollowRequest(userID, requesterID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if !found {
+		response.Error(w, http.StatusNotFound, errors.New("follow request not found"))
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"devbook/src/auth"
+	"devbook/src/config"
+	"devbook/src/database"
+	"devbook/src/media"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"devbook/src/storage"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// multipartOverhead leaves room for the multipart boundaries and headers
+// around the file when limiting the request body.
+const multipartOverhead = 1 << 20
+
+func UploadMedia(w http.ResponseWriter, r *http.Request) {
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if r.ContentLength > config.MediaMaxSize+multipartOverhead {
+		response.Error(w, http.StatusRequestEntityTooLarge, errors.New("file is too large"))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, config.MediaMaxSize+multipartOverhead)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > config.MediaMaxSize {
+		response.Error(w, http.StatusRequestEntityTooLarge, errors.New("file is too large"))
+		return
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(file, config.MediaMaxSize+1))
+	if err != nil {
+		response.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if int64(len(data)) > config.MediaMaxSize {
+		response.Error(w, http.StatusRequestEntityTooLarge, errors.New("file is too large"))
+		return
+	}
+
+	processed, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		writeError(w, err)
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	store, err := storage.Open()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	key, err := blobKey()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	item := models.Media{
+		UserID:      tokenUserID,
+		Kind:        processed.Kind,
+		ContentType: processed.ContentType,
+		Size:        int64(len(processed.Data)),
+		Width:       processed.Width,
+		Height:      processed.Height,
+		StorageKey:  key + processed.Extension,
+	}
+
+	if err = store.Put(item.StorageKey, processed.Data, item.ContentType); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if processed.Thumbnail != nil {
+		item.ThumbnailKey = key + "_thumb" + extension(processed.ThumbnailType)
+		if err = store.Put(item.ThumbnailKey, processed.Thumbnail, processed.ThumbnailType); err != nil {
+			deleteBlobs(r.Context(), []models.Media{item})
+			response.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		deleteBlobs(r.Context(), []models.Media{item})
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	item.ID, err = repositories.Media(db).Create(item)
+	if err != nil {
+		deleteBlobs(r.Context(), []models.Media{item})
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	item.Locate()
+	response.JSON(w, http.StatusCreated, item)
+}
+
+func GetMedia(w http.ResponseWriter, r *http.Request) {
+	item, ok := visibleMedia(w, r)
+	if !ok {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, item)
+}
+
+func GetMediaContent(w http.ResponseWriter, r *http.Request) {
+	item, ok := visibleMedia(w, r)
+	if !ok {
+		return
+	}
+
+	serveBlob(w, item.StorageKey, item.ContentType)
+}
+
+func GetMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	item, ok := visibleMedia(w, r)
+	if !ok {
+		return
+	}
+
+	if item.ThumbnailKey == "" {
+		response.Error(w, http.StatusNotFound, errors.New("media has no thumbnail"))
+		return
+	}
+
+	serveBlob(w, item.ThumbnailKey, mime.TypeByExtension(path.Ext(item.ThumbnailKey)))
+}
+
+func serveBlob(w http.ResponseWriter, key, contentType string) {
+	store, err := storage.Open()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	blob, err := store.Get(key)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, blob)
+}
+
+// visibleMedia loads the media named in the route. Media not attached to a
+// post yet is only visible to its uploader; otherwise it follows the
+// visibility of the post.
+func visibleMedia(w http.ResponseWriter, r *http.Request) (models.Media, bool) {
+	params := mux.Vars(r)
+
+	mediaID, err := strconv.ParseUint(params["mediaId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return models.Media{}, false
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return models.Media{}, false
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return models.Media{}, false
+	}
+	defer db.Close()
+
+	item, err := repositories.Media(db).FindOneById(mediaID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return models.Media{}, false
+	}
+
+	visible := item.ID != 0 && item.UserID == tokenUserID
+	if item.ID != 0 && !visible && item.PostID != 0 {
+		_, err := repositories.Posts(db).FindOneById(item.PostID, tokenUserID)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			response.Error(w, http.StatusInternalServerError, err)
+			return models.Media{}, false
+		}
+
+		visible = err == nil
+	}
+
+	if !visible {
+		response.Error(w, http.StatusNotFound, errors.New("media not found"))
+		return models.Media{}, false
+	}
+
+	return item, true
+}
+
+func attachMedia(db *sql.DB, posts []models.Post) error {
+	postIDs := make([]uint64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	found, err := repositories.Media(db).FindByPosts(postIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Media = found[posts[i].ID]
+	}
+
+	return nil
+}
+
+// deleteBlobs removes the stored files of media whose rows are gone. It is
+// best effort: a failure only leaves an orphaned file behind.
+func deleteBlobs(ctx context.Context, items []models.Media) {
+	store, err := storage.Open()
+	if err != nil {
+		slog.WarnContext(ctx, "opening media storage", "error", err)
+		return
+	}
+
+	for _, item := range items {
+		for _, key := range []string{item.StorageKey, item.ThumbnailKey} {
+			if key == "" {
+				continue
+			}
+
+			if err := store.Delete(key); err != nil {
+				slog.WarnContext(ctx, "deleting media blob", "key", key, "error", err)
+			}
+		}
+	}
+}
+
+// uniqueIDs drops repeated IDs, keeping the first occurrence of each.
+func uniqueIDs(ids []uint64) []uint64 {
+	seen := make(map[uint64]bool, len(ids))
+	unique := ids[:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return unique
+}
+
+func blobKey() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	return "media/" + hex.EncodeToString(random), nil
+}
+
+func extension(contentType string) string {
+	if contentType == "image/jpeg" {
+		return ".jpg"
+	}
+
+	return ".png"
+}
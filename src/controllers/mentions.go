@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"database/sql"
+	"devbook/src/auth"
+	"devbook/src/database"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+func GetMentions(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Posts(db)
+	posts, err := repository.FindMentioning(userID, tokenUserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err = attachDetails(db, posts, tokenUserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, posts)
+}
+
+// saveMentions resolves the @nick tokens of post against existing users and
+// stores them. It also returns the mentions of users that were not
+// mentioned before, for the caller to notify once the post is published.
+func saveMentions(db *sql.DB, post models.Post) ([]models.Mention, []models.Mention, error) {
+	extracted := models.ExtractMentions(post.Content)
+
+	nicks := make([]string, 0, len(extracted))
+	for _, mention := range extracted {
+		nicks = append(nicks, mention.Nick)
+	}
+
+	users, err := repositories.Users(db).FindByNicks(nicks, post.AuthorID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	usersByNick := make(map[string]models.User, len(users))
+	for _, user := range users {
+		usersByNick[strings.ToLower(user.Nick)] = user
+	}
+
+	var mentions []models.Mention
+	for _, mention := range extracted {
+		user, ok := usersByNick[strings.ToLower(mention.Nick)]
+		if !ok {
+			continue
+		}
+
+		mention.UserID = user.ID
+		mention.Nick = user.Nick
+		mentions = append(mentions, mention)
+	}
+
+	repository := repositories.Mentions(db)
+	previous, err := repository.FindByPost(post.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = repository.Save(post.ID, mentions); err != nil {
+		return nil, nil, err
+	}
+
+	mentioned := make(map[uint64]bool, len(previous))
+	for _, mention := range previous {
+		mentioned[mention.UserID] = true
+	}
+
+	var added []models.Mention
+	for _, mention := range mentions {
+		if !mentioned[mention.UserID] {
+			added = append(added, mention)
+		}
+	}
+
+	return mentions, added, nil
+}
+
+func attachMentions(db *sql.DB, posts []models.Post) error {
+	postIDs := make([]uint64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	mentions, err := repositories.Mentions(db).FindByPosts(postIDs)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Mentions = mentions[posts[i].ID]
+	}
+
+	return nil
+}
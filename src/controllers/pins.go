@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"devbook/src/auth"
+	"devbook/src/database"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func PinPost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Posts(db)
+	postByID, err := repository.FindOneById(postID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if postByID.AuthorID != tokenUserID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+
+	if postByID.Status != models.StatusPublished {
+		response.Error(w, http.StatusBadRequest, errors.New("only published posts can be pinned"))
+		return
+	}
+
+	err = repository.Pin(tokenUserID, postID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func UnpinPost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Posts(db)
+	postByID, err := repository.FindOneById(postID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if postByID.AuthorID != tokenUserID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+
+	if err = repository.Unpin(tokenUserID, postID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
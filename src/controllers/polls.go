@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"database/sql"
+	"devbook/src/auth"
+	"devbook/src/database"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func VotePoll(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var ballot struct {
+		OptionIDs []uint64 `json:"option_ids"`
+	}
+	if err = json.Unmarshal(body, &ballot); err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	ballot.OptionIDs = uniqueIDs(ballot.OptionIDs)
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	post, err := repositories.Posts(db).FindOneById(postID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if post.Status != models.StatusPublished {
+		response.Error(w, http.StatusNotFound, errors.New("post not found"))
+		return
+	}
+
+	repository := repositories.Polls(db)
+	polls, err := repository.FindByPosts([]uint64{postID}, tokenUserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	poll, ok := polls[postID]
+	if !ok {
+		response.Error(w, http.StatusNotFound, errors.New("post has no poll"))
+		return
+	}
+
+	if err = poll.ValidateVote(ballot.OptionIDs); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	err = repository.Vote(postID, tokenUserID, ballot.OptionIDs)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	posts := []models.Post{post}
+	if err = attachPolls(db, posts, tokenUserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, posts[0].Poll)
+}
+
+func attachPolls(db *sql.DB, posts []models.Post, viewerID uint64) error {
+	postIDs := make([]uint64, len(posts))
+	for i, post := range posts {
+		postIDs[i] = post.ID
+	}
+
+	polls, err := repositories.Polls(db).FindByPosts(postIDs, viewerID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range posts {
+		if poll, ok := polls[posts[i].ID]; ok {
+			poll.Summarize(viewerID, posts[i].AuthorID, now)
+			posts[i].Poll = poll
+		}
+	}
+
+	return nil
+}
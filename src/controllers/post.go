@@ -1,16 +1,26 @@
 package controllers
 
 import (
+	"database/sql"
 	"devbook/src/auth"
+	"devbook/src/config"
 	"devbook/src/database"
+	"devbook/src/etag"
+	"devbook/src/events"
+	"devbook/src/feed"
 	"devbook/src/models"
+	"devbook/src/notifications"
+	"devbook/src/patch"
+	"devbook/src/publishing"
 	"devbook/src/repositories"
 	"devbook/src/response"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -37,7 +47,20 @@ func CreatePost(w http.ResponseWriter, r *http.Request) {
 	post.AuthorID = tokenUserID
 
 	if err = post.Prepare(); err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		writeError(w, err)
+		return
+	}
+
+	if post.Poll != nil {
+		if err = post.Poll.Prepare(); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+
+	post.MediaIDs = uniqueIDs(post.MediaIDs)
+	if len(post.MediaIDs) > config.MediaMaxPerPost {
+		response.Error(w, http.StatusBadRequest, fmt.Errorf("a post can have at most %d media attachments", config.MediaMaxPerPost))
 		return
 	}
 
@@ -49,15 +72,60 @@ func CreatePost(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.Posts(db)
+	if post.QuoteOfID != 0 {
+		quoted, err := repository.FindOneById(post.QuoteOfID, tokenUserID)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			response.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err != nil || quoted.Status != models.StatusPublished {
+			response.Error(w, http.StatusBadRequest, errors.New("quoted post not found"))
+			return
+		}
+	}
+
 	post.ID, err = repository.Create(post)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	post.Media, err = repositories.Media(db).FindByPost(post.ID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	post.Mentions, _, err = saveMentions(db, post)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if post.Poll != nil {
+		post.Poll.Summarize(tokenUserID, post.AuthorID, time.Now())
+	}
+
+	posts := []models.Post{post}
+	if err = attachQuotes(db, posts, tokenUserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	post = posts[0]
+
+	if post.Status == models.StatusPublished {
+		if err = publishing.Announce(db, post); err != nil {
+			response.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
 	response.JSON(w, http.StatusCreated, post)
 }
 
+// GetPosts returns the home feed, ordered by the strategy named in ?rank=,
+// or the results of a search when ?q= is given.
 func GetPosts(w http.ResponseWriter, r *http.Request) {
 	tokenUserID, err := auth.ExtractUserID(r)
 	if err != nil {
@@ -65,20 +133,35 @@ func GetPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ranker, err := feedRanker(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 	}
 	defer db.Close()
 
-	repository := repositories.Posts(db)
-	posts, err := repository.Find(tokenUserID)
+	var posts []models.Post
+	if queryString := r.URL.Query().Get("q"); queryString != "" {
+		posts, err = repositories.Posts(db).Search(queryString, tokenUserID)
+	} else {
+		posts, err = feed.Home(r.Context(), db, tokenUserID, ranker)
+	}
 
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err = attachDetails(db, posts, tokenUserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	response.JSON(w, http.StatusOK, posts)
 }
 
@@ -91,6 +174,12 @@ func GetPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
@@ -98,25 +187,38 @@ func GetPost(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.Posts(db)
-	post, err := repository.FindOneById(postID)
+	post, err := repository.FindOneById(postID, tokenUserID)
 
 	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	posts := []models.Post{post}
+	if err = attachDetails(db, posts, tokenUserID); err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
+	post = posts[0]
 
-	response.JSON(w, http.StatusOK, post)
+	writeConditionally(w, r, tokenUserID, post.Version, post)
 }
 
 func GetPostsByUser(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	userID, err := strconv.ParseUint(params["postId"], 10, 64)
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
 	if err != nil {
 		response.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
@@ -124,13 +226,54 @@ func GetPostsByUser(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.Posts(db)
-	posts, err := repository.FindByUser(userID)
+	posts, err := repository.FindByUser(userID, tokenUserID)
+
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err = attachDetails(db, posts, tokenUserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, posts)
+}
+
+func GetDrafts(w http.ResponseWriter, r *http.Request) {
+	getOwnPostsByStatus(w, r, models.StatusDraft)
+}
+
+func GetScheduledPosts(w http.ResponseWriter, r *http.Request) {
+	getOwnPostsByStatus(w, r, models.StatusScheduled)
+}
+
+func getOwnPostsByStatus(w http.ResponseWriter, r *http.Request, status string) {
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
 
+	posts, err := repositories.Posts(db).FindByStatus(tokenUserID, status)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err = attachDetails(db, posts, tokenUserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	response.JSON(w, http.StatusOK, posts)
 }
 
@@ -157,9 +300,9 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.Posts(db)
-	postByID, err := repository.FindOneById(postID)
+	postByID, err := repository.FindOneById(postID, tokenUserID)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		writeError(w, err)
 		return
 	}
 
@@ -168,6 +311,10 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkPreconditions(w, r, postByID.Version) {
+		return
+	}
+
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		response.Error(w, http.StatusUnprocessableEntity, err)
@@ -180,20 +327,50 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if post.Visibility == "" {
+		post.Visibility = postByID.Visibility
+	}
+
+	if post.Status == "" {
+		post.Status = postByID.Status
+		if post.PublishAt == nil {
+			post.PublishAt = postByID.PublishAt
+		}
+	}
+
 	if err = post.Prepare(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = checkStatusChange(postByID, post); err != nil {
 		response.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
-	if err = repository.Update(postID, post); err != nil {
+	if err = repository.Update(postID, postByID.Version, post); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	post.ID = postID
+	post.AuthorID = postByID.AuthorID
+	_, added, err := saveMentions(db, post)
+	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err = announceEdit(db, postByID, post, added); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	etag.Set(w, postByID.Version+1)
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
-func DeletePost(w http.ResponseWriter, r *http.Request) {
+func PatchPost(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
 	postID, err := strconv.ParseUint(params["postId"], 10, 64)
@@ -208,6 +385,12 @@ func DeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
@@ -216,22 +399,137 @@ func DeletePost(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.Posts(db)
-	postByID, err := repository.FindOneById(postID)
+	postByID, err := repository.FindOneById(postID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if postByID.AuthorID != tokenUserID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+
+	if !checkPreconditions(w, r, postByID.Version) {
+		return
+	}
+
+	original, err := json.Marshal(postByID.Editable())
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	patched, err := patch.Apply(r.Header.Get("Content-Type"), original, body)
+	if err == patch.ErrUnsupportedType {
+		response.Error(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var editable models.EditablePost
+	if err = json.Unmarshal(patched, &editable); err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	post := postByID.Edited(editable)
+
+	if err = post.Prepare(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = checkStatusChange(postByID, post); err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	changes := postByID.Changes(post)
+	if err = repository.UpdateColumns(postID, postByID.Version, changes); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	post.ID = postID
+	post.AuthorID = postByID.AuthorID
+
+	var added []models.Mention
+	if _, ok := changes["content"]; ok {
+		if _, added, err = saveMentions(db, post); err != nil {
+			response.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	if err = announceEdit(db, postByID, post, added); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if len(changes) > 0 {
+		postByID.Version++
+	}
+
+	etag.Set(w, postByID.Version)
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func DeletePost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
+	defer db.Close()
+
+	repository := repositories.Posts(db)
+	postByID, err := repository.FindOneById(postID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 
 	if postByID.AuthorID != tokenUserID {
 		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
 		return
 	}
 
-	if err = repository.Delete(postID); err != nil {
+	if !checkPreconditions(w, r, postByID.Version) {
+		return
+	}
+
+	attached, err := repositories.Media(db).FindByPost(postID)
+	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err = repository.Delete(postID, postByID.Version); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	deleteBlobs(r.Context(), attached)
+	events.Publish(events.PostDeleted, postByID)
+
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -244,6 +542,12 @@ func Like(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
@@ -252,11 +556,30 @@ func Like(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.Posts(db)
-	if err = repository.Like(postID); err != nil {
+	post, err := repository.FindOneById(postID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	liked, err := repository.Like(postID, tokenUserID)
+	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if !liked {
+		response.JSON(w, http.StatusNoContent, nil)
+		return
+	}
+
+	if err = notifications.Like(db, post, tokenUserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	events.Publish(events.PostLiked, events.Like{PostID: postID, UserID: tokenUserID}, post.AuthorID)
+
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -269,6 +592,12 @@ func Unlike(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
@@ -277,10 +606,61 @@ func Unlike(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.Posts(db)
-	if err = repository.Unlike(postID); err != nil {
+	if _, err = repository.FindOneById(postID, tokenUserID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = repository.Unlike(postID, tokenUserID); err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
 	response.JSON(w, http.StatusNoContent, nil)
 }
+
+// feedRanker picks the ranker from ?rank=. Catch-up starts at ?since=
+// (RFC 3339) or a day ago.
+func feedRanker(r *http.Request) (feed.Ranker, error) {
+	query := r.URL.Query()
+
+	since := time.Now().Add(-24 * time.Hour)
+	if value := query.Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, err
+		}
+		since = parsed
+	}
+
+	return feed.NewRanker(query.Get("rank"), since)
+}
+
+// checkStatusChange refuses to take a post that already went out back to
+// draft or scheduled.
+func checkStatusChange(before, after models.Post) error {
+	if before.Status == models.StatusPublished && after.Status != models.StatusPublished {
+		return errors.New("a published post cannot become a draft or be scheduled")
+	}
+
+	return nil
+}
+
+// announceEdit notifies users newly mentioned in an edit of a published
+// post, or announces the post when the edit is what published it.
+func announceEdit(db *sql.DB, before, after models.Post, added []models.Mention) error {
+	if before.Status == models.StatusPublished {
+		return publishing.NotifyMentions(db, after, added)
+	}
+
+	if after.Status != models.StatusPublished {
+		return nil
+	}
+
+	post, err := repositories.Posts(db).FindOneById(after.ID, after.AuthorID)
+	if err != nil {
+		return err
+	}
+
+	return publishing.Announce(db, post)
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"devbook/src/etag"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// checkPreconditions answers 428 or 412 and returns false when the request
+// may not write to a resource currently at version.
+func checkPreconditions(w http.ResponseWriter, r *http.Request, version uint64) bool {
+	if etag.Missing(r) {
+		response.Error(w, http.StatusPreconditionRequired, errors.New("this request must be conditional, send an If-Match header"))
+		return false
+	}
+
+	if !etag.Matches(r, version) {
+		writeError(w, repositories.ErrVersionMismatch)
+		return false
+	}
+
+	return true
+}
+
+// writeConditionally answers a GET with data, a resource at version as
+// seen by viewerID, or with 304 when the If-None-Match header shows the
+// client already has it.
+func writeConditionally(w http.ResponseWriter, r *http.Request, viewerID, version uint64, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	tag := etag.Weak(version, body, viewerID)
+	w.Header().Set("Vary", "Authorization")
+	w.Header().Set("ETag", tag)
+
+	if etag.NotModified(r, tag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	response.Raw(w, http.StatusOK, body)
+}
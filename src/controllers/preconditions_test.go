@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"devbook/src/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type resource struct {
+	ID      uint64 `json:"id"`
+	Likes   uint64 `json:"likes"`
+	Version uint64 `json:"version"`
+}
+
+func get(t *testing.T, viewerID uint64, current resource, ifNoneMatch string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodGet, "/posts/1", nil)
+	if ifNoneMatch != "" {
+		r.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	w := httptest.NewRecorder()
+	writeConditionally(w, r, viewerID, current.Version, current)
+	return w
+}
+
+func put(ifMatch string, version uint64) (*httptest.ResponseRecorder, bool) {
+	r := httptest.NewRequest(http.MethodPut, "/posts/1", nil)
+	if ifMatch != "" {
+		r.Header.Set("If-Match", ifMatch)
+	}
+
+	w := httptest.NewRecorder()
+	return w, checkPreconditions(w, r, version)
+}
+
+func TestConditionalRoundTrip(t *testing.T) {
+	config.RequireIfMatch = true
+	defer func() { config.RequireIfMatch = false }()
+
+	current := resource{ID: 1, Likes: 2, Version: 4}
+
+	served := get(t, 7, current, "")
+	tag := served.Header().Get("ETag")
+	if served.Code != http.StatusOK || tag == "" {
+		t.Fatalf("GET answered %d with ETag %q", served.Code, tag)
+	}
+
+	if w, ok := put(tag, current.Version); !ok {
+		t.Errorf("PUT with the served ETag answered %d, want it allowed", w.Code)
+	}
+
+	if w, ok := put(`"4"`, current.Version); !ok {
+		t.Errorf("PUT with the version tag answered %d, want it allowed", w.Code)
+	}
+
+	if w, ok := put(tag, current.Version+1); ok || w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT after an edit answered %d, want 412", w.Code)
+	}
+
+	if w, ok := put("", current.Version); ok || w.Code != http.StatusPreconditionRequired {
+		t.Errorf("PUT without If-Match answered %d, want 428", w.Code)
+	}
+}
+
+func TestConditionalGet(t *testing.T) {
+	current := resource{ID: 1, Likes: 2, Version: 4}
+	tag := get(t, 7, current, "").Header().Get("ETag")
+
+	if w := get(t, 7, current, tag); w.Code != http.StatusNotModified {
+		t.Errorf("unchanged GET answered %d, want 304", w.Code)
+	}
+
+	liked := current
+	liked.Likes++
+	if w := get(t, 7, liked, tag); w.Code != http.StatusOK {
+		t.Errorf("GET after a like answered %d, want 200", w.Code)
+	}
+
+	if w := get(t, 8, current, tag); w.Code != http.StatusOK {
+		t.Errorf("GET by another viewer answered %d, want 200", w.Code)
+	}
+
+	if _, ok := put(get(t, 7, liked, "").Header().Get("ETag"), current.Version); !ok {
+		t.Error("a like changed the tag's version, so If-Match failed")
+	}
+}
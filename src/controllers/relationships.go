@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"devbook/src/auth"
+	"devbook/src/database"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const maxRelationships = 100
+
+func GetRelationship(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	relationships, err := repositories.Users(db).FindRelationships(tokenUserID, []uint64{userID})
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	relationship, ok := relationships[userID]
+	if !ok {
+		response.Error(w, http.StatusNotFound, errors.New("user not found"))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, relationship)
+}
+
+// GetRelationships is the batch form of GetRelationship for the
+// comma-separated users in ?ids=. Unknown users are left out.
+func GetRelationships(w http.ResponseWriter, r *http.Request) {
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	var userIDs []uint64
+	for _, value := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+
+		userID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, err)
+			return
+		}
+		userIDs = append(userIDs, userID)
+	}
+	userIDs = uniqueIDs(userIDs)
+
+	if len(userIDs) == 0 {
+		response.Error(w, http.StatusBadRequest, errors.New("ids is required"))
+		return
+	}
+
+	if len(userIDs) > maxRelationships {
+		response.Error(w, http.StatusBadRequest, fmt.Errorf("at most %d ids at a time", maxRelationships))
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	found, err := repositories.Users(db).FindRelationships(tokenUserID, userIDs)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	relationships := make([]models.Relationship, 0, len(found))
+	for _, userID := range userIDs {
+		if relationship, ok := found[userID]; ok {
+			relationships = append(relationships, relationship)
+		}
+	}
+
+	response.JSON(w, http.StatusOK, relationships)
+}
+
+func GetMutuals(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	allowed, err := canSeeConnections(db, userID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !allowed {
+		response.Error(w, http.StatusForbidden, errors.New("this account is private"))
+		return
+	}
+
+	mutuals, err := repositories.Users(db).GetMutuals(userID, tokenUserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err = hideEmails(db, tokenUserID, mutuals); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, mutuals)
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"devbook/src/auth"
+	"devbook/src/database"
+	"devbook/src/models"
+	"devbook/src/notifications"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func Repost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Posts(db)
+	post, err := repository.FindOneById(postID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if post.Status != models.StatusPublished {
+		response.Error(w, http.StatusNotFound, errors.New("post not found"))
+		return
+	}
+
+	// Reposting followers-only posts would hand them to a wider audience
+	// than the author chose.
+	if post.Visibility != models.VisibilityPublic {
+		response.Error(w, http.StatusForbidden, errors.New("only public posts can be reposted"))
+		return
+	}
+
+	reposted, err := repository.Repost(tokenUserID, postID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if reposted {
+		if err = notifications.Repost(db, post, tokenUserID); err != nil {
+			response.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func Unrepost(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	if err = repositories.Posts(db).Unrepost(tokenUserID, postID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
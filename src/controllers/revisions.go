@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"devbook/src/auth"
+	"devbook/src/database"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func GetPostRevisions(w http.ResponseWriter, r *http.Request) {
+	revisions, ok := visibleRevisions(w, r)
+	if !ok {
+		return
+	}
+
+	response.JSON(w, http.StatusOK, revisions)
+}
+
+// GetPostRevisionDiff compares the revisions given by ?from= and ?to=. By
+// default it shows what the latest edit changed.
+func GetPostRevisionDiff(w http.ResponseWriter, r *http.Request) {
+	revisions, ok := visibleRevisions(w, r)
+	if !ok {
+		return
+	}
+
+	byNumber := make(map[uint64]models.PostRevision, len(revisions))
+	var latest uint64
+	for _, revision := range revisions {
+		byNumber[revision.Revision] = revision
+		if revision.Revision > latest {
+			latest = revision.Revision
+		}
+	}
+
+	to, err := revisionParam(r, "to", latest)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	from, err := revisionParam(r, "from", to-1)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	fromRevision, fromOK := byNumber[from]
+	toRevision, toOK := byNumber[to]
+	if !fromOK || !toOK {
+		response.Error(w, http.StatusNotFound, errors.New("revision not found"))
+		return
+	}
+
+	response.JSON(w, http.StatusOK, models.Compare(fromRevision, toRevision))
+}
+
+func revisionParam(r *http.Request, name string, fallback uint64) (uint64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// visibleRevisions loads the revisions of the post named in the route,
+// answering 404 when the caller cannot see the post.
+func visibleRevisions(w http.ResponseWriter, r *http.Request) ([]models.PostRevision, bool) {
+	params := mux.Vars(r)
+
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return nil, false
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	defer db.Close()
+
+	_, err = repositories.Posts(db).FindOneById(postID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+
+	revisions, err := repositories.Revisions(db).FindByPost(postID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	return revisions, true
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"devbook/src/auth"
+	"devbook/src/config"
+	"devbook/src/database"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"devbook/src/trending"
+	"fmt"
+	"net/http"
+)
+
+func GetTrendingPosts(w http.ResponseWriter, r *http.Request) {
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	window, err := trending.FindWindow(r.URL.Query().Get("window"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	snapshot := trending.Get(window)
+
+	postIDs := make([]uint64, len(snapshot.Posts))
+	for i, trend := range snapshot.Posts {
+		postIDs[i] = trend.Post.ID
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	found, err := repositories.Posts(db).FindByIDs(postIDs, tokenUserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	muted, err := repositories.Users(db).GetMuted(tokenUserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	mutedIDs := make(map[uint64]bool, len(muted))
+	for _, user := range muted {
+		mutedIDs[user.ID] = true
+	}
+
+	// The snapshot is shared by everyone; what each viewer may see of it
+	// is decided here.
+	var trends []models.TrendingPost
+	var posts []models.Post
+	for _, trend := range snapshot.Posts {
+		post, ok := found[trend.Post.ID]
+		if !ok || mutedIDs[post.AuthorID] {
+			continue
+		}
+
+		trends = append(trends, trend)
+		posts = append(posts, post)
+	}
+
+	if err = attachDetails(db, posts, tokenUserID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for i := range trends {
+		trends[i].Post = posts[i]
+	}
+
+	cacheTrends(w)
+	response.JSON(w, http.StatusOK, trends)
+}
+
+func GetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	window, err := trending.FindWindow(r.URL.Query().Get("window"))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	cacheTrends(w)
+	response.JSON(w, http.StatusOK, trending.Get(window).Tags)
+}
+
+// cacheTrends lets clients keep trends until the job recomputes them.
+func cacheTrends(w http.ResponseWriter) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(config.TrendingInterval.Seconds())))
+}
@@ -1,9 +1,14 @@
 package controllers
 
 import (
+	"database/sql"
 	"devbook/src/auth"
 	"devbook/src/database"
+	"devbook/src/etag"
+	"devbook/src/events"
 	"devbook/src/models"
+	"devbook/src/notifications"
+	"devbook/src/patch"
 	"devbook/src/repositories"
 	"devbook/src/response"
 	"encoding/json"
@@ -30,7 +35,7 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err = user.Prepare("create"); err != nil {
-		response.Error(w, http.StatusBadRequest, err)
+		writeError(w, err)
 		return
 	}
 
@@ -44,7 +49,7 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 	repository := repositories.Users(db)
 	user.ID, err = repository.Create(user)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, err)
+		writeError(w, err)
 		return
 	}
 
@@ -54,20 +59,32 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 func GetUsers(w http.ResponseWriter, r *http.Request) {
 	queryString := strings.ToLower(r.URL.Query().Get("user"))
 
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
+		return
 	}
 	defer db.Close()
 
 	repository := repositories.Users(db)
-	users, err := repository.Find(queryString)
+	users, err := repository.Find(queryString, tokenUserID)
 
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err = hideEmails(db, tokenUserID, users); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	response.JSON(w, http.StatusOK, users)
 }
 
@@ -80,21 +97,78 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
+		return
 	}
 	defer db.Close()
 
 	repository := repositories.Users(db)
-	user, err := repository.FindOneById(userID)
+	user, err := repository.FindOneById(userID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 
+	user.PinnedPostIDs, err = repositories.Posts(db).FindPinnedIDs(user.ID, tokenUserID)
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	response.JSON(w, http.StatusOK, user)
+	users := []models.User{user}
+	if err = hideEmails(db, tokenUserID, users); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeConditionally(w, r, tokenUserID, users[0].Version, users[0])
+}
+
+func GetUserByNick(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	nick := params["nick"]
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	user, err := repository.FindOneByNick(nick, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	user.PinnedPostIDs, err = repositories.Posts(db).FindPinnedIDs(user.ID, tokenUserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	users := []models.User{user}
+	if err = hideEmails(db, tokenUserID, users); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeConditionally(w, r, tokenUserID, users[0].Version, users[0])
 }
 
 func UpdateUser(w http.ResponseWriter, r *http.Request) {
@@ -130,10 +204,63 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err = user.Prepare("update"); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Users(db)
+	current, err := repository.FindOneById(userID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !checkPreconditions(w, r, current.Version) {
+		return
+	}
+
+	if err = repository.Update(userID, current.Version, user); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	etag.Set(w, current.Version+1)
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func PatchUser(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
 		response.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	if userID != tokenUserID {
+		response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
@@ -142,11 +269,56 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.Users(db)
-	if err = repository.Update(userID, user); err != nil {
+	user, err := repository.FindOneById(userID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !checkPreconditions(w, r, user.Version) {
+		return
+	}
+
+	original, err := json.Marshal(user.Editable())
+	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	patched, err := patch.Apply(r.Header.Get("Content-Type"), original, body)
+	if err == patch.ErrUnsupportedType {
+		response.Error(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var editable models.EditableUser
+	if err = json.Unmarshal(patched, &editable); err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	updated := user.Edited(editable)
+
+	if err = updated.Prepare("update"); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	changes := user.Changes(updated)
+	if err = repository.UpdateColumns(userID, user.Version, changes); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if len(changes) > 0 {
+		user.Version++
+	}
+
+	etag.Set(w, user.Version)
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -177,11 +349,29 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 	defer db.Close()
 
 	repository := repositories.Users(db)
-	if err = repository.Delete(userID); err != nil {
+	current, err := repository.FindOneById(userID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !checkPreconditions(w, r, current.Version) {
+		return
+	}
+
+	uploaded, err := repositories.Media(db).FindByUser(userID)
+	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err = repository.Delete(userID, current.Version); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	deleteBlobs(r.Context(), uploaded)
+
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -272,15 +462,55 @@ func FollowUser(w http.ResponseWriter, r *http.Request) {
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
+		return
 	}
 	defer db.Close()
 
 	repository := repositories.Users(db)
+	user, err := repository.FindOneById(userID, followID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if user.Private {
+		following, err := repository.IsFollowing(userID, followID)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if following {
+			response.JSON(w, http.StatusNoContent, nil)
+			return
+		}
+
+		if err = repository.RequestFollow(userID, followID); err != nil {
+			response.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err = notifications.FollowRequest(db, userID, followID); err != nil {
+			response.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		response.JSON(w, http.StatusAccepted, nil)
+		return
+	}
+
 	if err = repository.Follow(userID, followID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err = notifications.Follow(db, userID, followID); err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	events.Publish(events.UserFollowed, events.Follow{UserID: userID, FollowerID: followID}, userID)
+
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -315,6 +545,8 @@ func UnfollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	events.Publish(events.UserUnfollowed, events.Follow{UserID: userID, FollowerID: followID})
+
 	response.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -327,20 +559,43 @@ func GetFollowers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
+		return
 	}
 	defer db.Close()
 
 	repository := repositories.Users(db)
-	followers, err := repository.GetFollowers(userID)
+	allowed, err := canSeeConnections(db, userID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !allowed {
+		response.Error(w, http.StatusForbidden, errors.New("this account is private"))
+		return
+	}
+
+	followers, err := repository.GetFollowers(userID, tokenUserID)
 
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err = hideEmails(db, tokenUserID, followers); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	response.JSON(w, http.StatusOK, followers)
 }
 
@@ -353,19 +608,79 @@ func GetFollowing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
 	db, err := database.Connection()
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
+		return
 	}
 	defer db.Close()
 
 	repository := repositories.Users(db)
-	followers, err := repository.GetFollowing(userID)
+	allowed, err := canSeeConnections(db, userID, tokenUserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !allowed {
+		response.Error(w, http.StatusForbidden, errors.New("this account is private"))
+		return
+	}
+
+	followers, err := repository.GetFollowing(userID, tokenUserID)
 
 	if err != nil {
 		response.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if err = hideEmails(db, tokenUserID, followers); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
 	response.JSON(w, http.StatusOK, followers)
 }
+
+// canSeeConnections reports whether viewerID may list who userID follows and
+// is followed by. Private accounts only show them to approved followers.
+func canSeeConnections(db *sql.DB, userID, viewerID uint64) (bool, error) {
+	if userID == viewerID {
+		return true, nil
+	}
+
+	repository := repositories.Users(db)
+	user, err := repository.FindOneById(userID, viewerID)
+	if err != nil {
+		return false, err
+	}
+
+	if !user.Private {
+		return true, nil
+	}
+
+	return repository.IsFollowing(userID, viewerID)
+}
+
+// hideEmails blanks the email of every user other than the viewer, unless
+// the viewer is an admin.
+func hideEmails(db *sql.DB, viewerID uint64, users []models.User) error {
+	admin, err := repositories.Users(db).IsAdmin(viewerID)
+	if err != nil {
+		return err
+	}
+
+	for i := range users {
+		if !admin && users[i].ID != viewerID {
+			users[i].Email = ""
+		}
+	}
+
+	return nil
+}
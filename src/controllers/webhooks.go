@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"devbook/src/auth"
+	"devbook/src/database"
+	"devbook/src/events"
+	"devbook/src/models"
+	"devbook/src/netguard"
+	"devbook/src/repositories"
+	"devbook/src/response"
+	"devbook/src/webhooks"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		response.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	var webhook models.Webhook
+	if err = json.Unmarshal(body, &webhook); err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = webhook.Prepare(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Delivery checks every address it dials as well; this only turns away
+	// hosts that already resolve somewhere internal before they are saved.
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	target, _ := url.Parse(webhook.URL)
+	if err = netguard.CheckHost(ctx, target.Hostname()); err != nil {
+		response.Error(w, http.StatusBadRequest, fmt.Errorf("url %s: %v", target.Hostname(), err))
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	if webhook.Global {
+		admin, err := repositories.Users(db).IsAdmin(tokenUserID)
+		if err != nil {
+			response.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !admin {
+			response.Error(w, http.StatusForbidden, errors.New("only admins can register global webhooks"))
+			return
+		}
+	}
+
+	webhook.UserID = tokenUserID
+	webhook.Active = true
+	webhook.Secret, err = webhooks.GenerateSecret()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	repository := repositories.Webhooks(db)
+	webhook.ID, err = repository.Create(webhook)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusCreated, webhook)
+}
+
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Webhooks(db)
+	webhooks, err := repository.FindByUser(tokenUserID)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	response.JSON(w, http.StatusOK, webhooks)
+}
+
+func GetWebhook(w http.ResponseWriter, r *http.Request) {
+	db, webhook, ok := ownWebhook(w, r)
+	if !ok {
+		return
+	}
+	db.Close()
+
+	webhook.Secret = ""
+	response.JSON(w, http.StatusOK, webhook)
+}
+
+func DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	db, webhook, ok := ownWebhook(w, r)
+	if !ok {
+		return
+	}
+	defer db.Close()
+
+	repository := repositories.Webhooks(db)
+	if err := repository.Delete(webhook.ID); err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusNoContent, nil)
+}
+
+func GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	db, webhook, ok := ownWebhook(w, r)
+	if !ok {
+		return
+	}
+	defer db.Close()
+
+	limit, offset := pagination(r)
+
+	repository := repositories.Webhooks(db)
+	deliveries, err := repository.FindDeliveries(webhook.ID, limit, offset)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, deliveries)
+}
+
+func TestWebhook(w http.ResponseWriter, r *http.Request) {
+	db, webhook, ok := ownWebhook(w, r)
+	if !ok {
+		return
+	}
+	defer db.Close()
+
+	event := events.Event{
+		Type: webhooks.TestEvent,
+		Data: struct {
+			WebhookID uint64 `json:"webhook_id"`
+		}{webhook.ID},
+		CreatedAt: time.Now(),
+	}
+
+	payload, err := webhooks.Payload(event)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	delivery := webhooks.NewDeliverer().Send(webhook, event, payload, 1)
+
+	repository := repositories.Webhooks(db)
+	delivery.ID, err = repository.CreateDelivery(delivery)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, delivery)
+}
+
+// ownWebhook loads the webhook named in the route and makes sure the caller
+// owns it or is an admin. On success the caller must close the returned db.
+func ownWebhook(w http.ResponseWriter, r *http.Request) (*sql.DB, models.Webhook, bool) {
+	params := mux.Vars(r)
+
+	webhookID, err := strconv.ParseUint(params["webhookId"], 10, 64)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err)
+		return nil, models.Webhook{}, false
+	}
+
+	tokenUserID, err := auth.ExtractUserID(r)
+	if err != nil {
+		response.Error(w, http.StatusUnauthorized, err)
+		return nil, models.Webhook{}, false
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err)
+		return nil, models.Webhook{}, false
+	}
+
+	webhook, err := repositories.Webhooks(db).FindOneById(webhookID)
+	if err != nil {
+		db.Close()
+		writeError(w, err)
+		return nil, models.Webhook{}, false
+	}
+
+	if webhook.UserID != tokenUserID {
+		admin, err := repositories.Users(db).IsAdmin(tokenUserID)
+		if err != nil {
+			db.Close()
+			response.Error(w, http.StatusInternalServerError, err)
+			return nil, models.Webhook{}, false
+		}
+
+		if !admin {
+			db.Close()
+			response.Error(w, http.StatusForbidden, errors.New("forbidden"))
+			return nil, models.Webhook{}, false
+		}
+	}
+
+	return db, webhook, true
+}
@@ -0,0 +1,70 @@
+package diff
+
+import "regexp"
+
+const (
+	Equal  = "equal"
+	Insert = "insert"
+	Delete = "delete"
+)
+
+type Op struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+var tokens = regexp.MustCompile(`\s+|\S+`)
+
+// Words diffs a and b word by word, keeping whitespace as its own token so
+// the ops concatenate back to either text.
+func Words(a, b string) []Op {
+	from := tokens.FindAllString(a, -1)
+	to := tokens.FindAllString(b, -1)
+
+	// lengths[i][j] is the length of the longest common subsequence of
+	// from[i:] and to[j:].
+	lengths := make([][]int, len(from)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(to)+1)
+	}
+
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	ops := []Op{}
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i] == to[j]:
+			ops = appendOp(ops, Equal, from[i])
+			i++
+			j++
+		case i < len(from) && (j == len(to) || lengths[i+1][j] >= lengths[i][j+1]):
+			ops = appendOp(ops, Delete, from[i])
+			i++
+		default:
+			ops = appendOp(ops, Insert, to[j])
+			j++
+		}
+	}
+
+	return ops
+}
+
+func appendOp(ops []Op, opType, text string) []Op {
+	if len(ops) > 0 && ops[len(ops)-1].Type == opType {
+		ops[len(ops)-1].Text += text
+		return ops
+	}
+
+	return append(ops, Op{Type: opType, Text: text})
+}
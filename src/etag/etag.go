@@ -0,0 +1,84 @@
+package etag
+
+import (
+	"crypto/sha256"
+	"devbook/src/config"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Format is the strong tag of a version, the one writes are checked against.
+func Format(version uint64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+func Set(w http.ResponseWriter, version uint64) {
+	w.Header().Set("ETag", Format(version))
+}
+
+// Weak is the tag of a GET response body as served to viewerID. Likes,
+// reposts, votes and per-viewer flags change the body without a new
+// version, so reads are tagged by their content too. The version leads the
+// tag so clients can send it back in If-Match.
+func Weak(version uint64, body []byte, viewerID uint64) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n", viewerID)
+	hash.Write(body)
+
+	return fmt.Sprintf(`W/"%d-%x"`, version, hash.Sum(nil)[:16])
+}
+
+// Missing reports whether a write must be refused with 428 because it has
+// no If-Match header while conditional writes are required.
+func Missing(r *http.Request) bool {
+	return config.RequireIfMatch && r.Header.Get("If-Match") == ""
+}
+
+// Matches reports whether the If-Match header allows a write to a resource
+// currently at version. Requests without the header always match. Tags
+// served on GET match as long as the version they carry is current, since
+// only edits conflict with a write.
+func Matches(r *http.Request, version uint64) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+
+	return contains(header, version)
+}
+
+// NotModified reports whether the If-None-Match header names tag, using
+// the weak comparison RFC 9110 asks for, so a GET can answer 304.
+func NotModified(r *http.Request, tag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// contains reports whether header names version, either as its strong tag
+// or as a tag served by Weak for it.
+func contains(header string, version uint64) bool {
+	current := Format(version)
+	served := fmt.Sprintf(`"%d-`, version)
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == current || strings.HasPrefix(strings.TrimPrefix(tag, "W/"), served) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,177 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	PostCreated         = "post.created"
+	PostLiked           = "post.liked"
+	PostDeleted         = "post.deleted"
+	UserFollowed        = "user.followed"
+	UserUnfollowed      = "user.unfollowed"
+	NotificationCreated = "notification.created"
+)
+
+const (
+	replaySize       = 1024
+	subscriberBuffer = 64
+)
+
+type Like struct {
+	PostID uint64 `json:"post_id"`
+	UserID uint64 `json:"user_id"`
+}
+
+type Follow struct {
+	UserID     uint64 `json:"user_id"`
+	FollowerID uint64 `json:"follower_id"`
+}
+
+type Event struct {
+	ID         uint64      `json:"id"`
+	Type       string      `json:"type"`
+	Data       interface{} `json:"data"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Recipients []uint64    `json:"-"`
+}
+
+func (event Event) RelevantTo(userID uint64) bool {
+	for _, recipient := range event.Recipients {
+		if recipient == userID {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Subscription struct {
+	userID uint64
+	all    bool
+	events chan Event
+	bus    *Bus
+}
+
+func (subscription *Subscription) wants(event Event) bool {
+	return subscription.all || event.RelevantTo(subscription.userID)
+}
+
+// Events is closed when the subscription is closed, including when the bus
+// drops it for not keeping up with published events.
+func (subscription *Subscription) Events() <-chan Event {
+	return subscription.events
+}
+
+func (subscription *Subscription) Close() {
+	subscription.bus.unsubscribe(subscription)
+}
+
+type Bus struct {
+	mutex       sync.Mutex
+	lastID      uint64
+	replay      []Event
+	replaySize  int
+	bufferSize  int
+	subscribers map[*Subscription]struct{}
+}
+
+func NewBus(replaySize, bufferSize int) *Bus {
+	return &Bus{
+		replaySize:  replaySize,
+		bufferSize:  bufferSize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+func (bus *Bus) Publish(eventType string, data interface{}, recipients ...uint64) Event {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	bus.lastID++
+	event := Event{
+		ID:         bus.lastID,
+		Type:       eventType,
+		Data:       data,
+		CreatedAt:  time.Now(),
+		Recipients: recipients,
+	}
+
+	bus.replay = append(bus.replay, event)
+	if len(bus.replay) > bus.replaySize {
+		bus.replay = bus.replay[len(bus.replay)-bus.replaySize:]
+	}
+
+	for subscription := range bus.subscribers {
+		if !subscription.wants(event) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			delete(bus.subscribers, subscription)
+			close(subscription.events)
+		}
+	}
+
+	return event
+}
+
+// Subscribe registers userID for new events and returns the buffered events
+// published after lastEventID, so a reconnecting client misses nothing that
+// is still in the replay buffer.
+func (bus *Bus) Subscribe(userID, lastEventID uint64) (*Subscription, []Event) {
+	return bus.subscribe(&Subscription{userID: userID}, lastEventID)
+}
+
+// SubscribeAll receives every published event regardless of its recipients.
+// It is meant for in-process consumers such as the webhook worker.
+func (bus *Bus) SubscribeAll(lastEventID uint64) (*Subscription, []Event) {
+	return bus.subscribe(&Subscription{all: true}, lastEventID)
+}
+
+func (bus *Bus) subscribe(subscription *Subscription, lastEventID uint64) (*Subscription, []Event) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	var missed []Event
+	if lastEventID > 0 {
+		for _, event := range bus.replay {
+			if event.ID > lastEventID && subscription.wants(event) {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	subscription.events = make(chan Event, bus.bufferSize)
+	subscription.bus = bus
+	bus.subscribers[subscription] = struct{}{}
+
+	return subscription, missed
+}
+
+func (bus *Bus) unsubscribe(subscription *Subscription) {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	if _, ok := bus.subscribers[subscription]; ok {
+		delete(bus.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+var bus = NewBus(replaySize, subscriberBuffer)
+
+func Publish(eventType string, data interface{}, recipients ...uint64) Event {
+	return bus.Publish(eventType, data, recipients...)
+}
+
+func Subscribe(userID, lastEventID uint64) (*Subscription, []Event) {
+	return bus.Subscribe(userID, lastEventID)
+}
+
+func SubscribeAll(lastEventID uint64) (*Subscription, []Event) {
+	return bus.SubscribeAll(lastEventID)
+}
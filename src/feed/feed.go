@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"devbook/src/models"
+	"devbook/src/timeline"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	RankChronological = "chronological"
+	RankEngagement    = "engagement"
+	RankCatchUp       = "catch-up"
+)
+
+// Ranker orders feed candidates. Implementations only look at the posts
+// and now, so the same input always gives the same order.
+type Ranker interface {
+	Rank(posts []models.Post, now time.Time)
+}
+
+// NewRanker returns the ranker named by the client, chronological when
+// name is empty. since is where catch-up starts.
+func NewRanker(name string, since time.Time) (Ranker, error) {
+	switch name {
+	case "", RankChronological:
+		return Chronological{}, nil
+	case RankEngagement:
+		return DefaultEngagement, nil
+	case RankCatchUp:
+		if since.IsZero() {
+			return nil, errors.New("catch-up needs a since time")
+		}
+		return CatchUp{Since: since, Engagement: DefaultEngagement}, nil
+	default:
+		return nil, fmt.Errorf("unknown ranking %q, use %s, %s or %s", name, RankChronological, RankEngagement, RankCatchUp)
+	}
+}
+
+// Home builds userID's home feed and orders it with ranker.
+func Home(ctx context.Context, db *sql.DB, userID uint64, ranker Ranker) ([]models.Post, error) {
+	posts, err := timeline.Read(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ranker.Rank(posts, time.Now())
+	return posts, nil
+}
+
+// Chronological puts the latest activity first; a repost counts from when
+// it was reposted.
+type Chronological struct{}
+
+func (Chronological) Rank(posts []models.Post, now time.Time) {
+	sort.SliceStable(posts, func(i, j int) bool {
+		return activity(posts[i]).After(activity(posts[j]))
+	})
+}
+
+// Engagement scores posts by their weighted interactions, halving the
+// score every HalfLife since the post appeared in the feed.
+type Engagement struct {
+	LikeWeight   float64
+	RepostWeight float64
+	HalfLife     time.Duration
+}
+
+var DefaultEngagement = Engagement{
+	LikeWeight:   1,
+	RepostWeight: 2,
+	HalfLife:     6 * time.Hour,
+}
+
+func (ranker Engagement) Rank(posts []models.Post, now time.Time) {
+	scores := make(map[uint64]float64, len(posts))
+	for _, post := range posts {
+		scores[post.ID] = ranker.Score(post, now)
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		if scores[posts[i].ID] != scores[posts[j].ID] {
+			return scores[posts[i].ID] > scores[posts[j].ID]
+		}
+		return activity(posts[i]).After(activity(posts[j]))
+	})
+}
+
+func (ranker Engagement) Score(post models.Post, now time.Time) float64 {
+	interactions := 1 +
+		ranker.LikeWeight*float64(post.Likes) +
+		ranker.RepostWeight*float64(post.RepostCount)
+
+	age := now.Sub(activity(post))
+	if age < 0 {
+		age = 0
+	}
+
+	return interactions * math.Pow(0.5, age.Hours()/ranker.HalfLife.Hours())
+}
+
+// CatchUp shows what happened since the last visit first, best first, and
+// then everything older in chronological order.
+type CatchUp struct {
+	Since      time.Time
+	Engagement Engagement
+}
+
+func (ranker CatchUp) Rank(posts []models.Post, now time.Time) {
+	var recent, older []models.Post
+	for _, post := range posts {
+		if activity(post).After(ranker.Since) {
+			recent = append(recent, post)
+		} else {
+			older = append(older, post)
+		}
+	}
+
+	ranker.Engagement.Rank(recent, now)
+	Chronological{}.Rank(older, now)
+
+	copy(posts, recent)
+	copy(posts[len(recent):], older)
+}
+
+func activity(post models.Post) time.Time {
+	if post.RepostedAt != nil {
+		return *post.RepostedAt
+	}
+
+	return post.CreatedAt
+}
@@ -0,0 +1,151 @@
+package feed
+
+import (
+	"devbook/src/models"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func ago(d time.Duration) time.Time {
+	return now.Add(-d)
+}
+
+func ids(posts []models.Post) []uint64 {
+	result := make([]uint64, len(posts))
+	for i, post := range posts {
+		result[i] = post.ID
+	}
+	return result
+}
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRank(t *testing.T) {
+	repostedAt := ago(time.Minute)
+
+	tests := []struct {
+		name   string
+		ranker Ranker
+		posts  []models.Post
+		want   []uint64
+	}{
+		{
+			name:   "chronological puts the latest first",
+			ranker: Chronological{},
+			posts: []models.Post{
+				{ID: 1, CreatedAt: ago(3 * time.Hour)},
+				{ID: 2, CreatedAt: ago(time.Hour)},
+				{ID: 3, CreatedAt: ago(2 * time.Hour)},
+			},
+			want: []uint64{2, 3, 1},
+		},
+		{
+			name:   "chronological counts reposts from when they were reposted",
+			ranker: Chronological{},
+			posts: []models.Post{
+				{ID: 1, CreatedAt: ago(time.Hour)},
+				{ID: 2, CreatedAt: ago(48 * time.Hour), RepostedAt: &repostedAt},
+			},
+			want: []uint64{2, 1},
+		},
+		{
+			name:   "chronological ignores engagement",
+			ranker: Chronological{},
+			posts: []models.Post{
+				{ID: 1, CreatedAt: ago(2 * time.Hour), Likes: 500},
+				{ID: 2, CreatedAt: ago(time.Hour)},
+			},
+			want: []uint64{2, 1},
+		},
+		{
+			name:   "engagement prefers more interactions at the same age",
+			ranker: DefaultEngagement,
+			posts: []models.Post{
+				{ID: 1, CreatedAt: ago(time.Hour), Likes: 1},
+				{ID: 2, CreatedAt: ago(time.Hour), Likes: 5, RepostCount: 2},
+			},
+			want: []uint64{2, 1},
+		},
+		{
+			name:   "engagement lets an old popular post sink below a fresh one",
+			ranker: DefaultEngagement,
+			posts: []models.Post{
+				// Five half-lives divide 1+40+2*10 = 61 by 32.
+				{ID: 1, CreatedAt: ago(30 * time.Hour), Likes: 40, RepostCount: 10},
+				{ID: 2, CreatedAt: ago(time.Minute), Likes: 1},
+			},
+			want: []uint64{2, 1},
+		},
+		{
+			name:   "engagement keeps a popular post on top within a half-life",
+			ranker: DefaultEngagement,
+			posts: []models.Post{
+				{ID: 1, CreatedAt: ago(3 * time.Hour), Likes: 40, RepostCount: 10},
+				{ID: 2, CreatedAt: ago(time.Minute), Likes: 1},
+			},
+			want: []uint64{1, 2},
+		},
+		{
+			name:   "engagement breaks ties by activity",
+			ranker: DefaultEngagement,
+			posts: []models.Post{
+				{ID: 1, CreatedAt: ago(time.Hour)},
+				{ID: 2, CreatedAt: ago(time.Hour)},
+			},
+			want: []uint64{1, 2},
+		},
+		{
+			name:   "catch-up puts unseen posts first, best first, then seen ones by time",
+			ranker: CatchUp{Since: ago(4 * time.Hour), Engagement: DefaultEngagement},
+			posts: []models.Post{
+				{ID: 1, CreatedAt: ago(10 * time.Hour), Likes: 100},
+				{ID: 2, CreatedAt: ago(time.Hour)},
+				{ID: 3, CreatedAt: ago(3 * time.Hour), Likes: 20},
+				{ID: 4, CreatedAt: ago(6 * time.Hour)},
+				{ID: 5, CreatedAt: ago(5 * time.Hour), Likes: 3},
+			},
+			want: []uint64{3, 2, 5, 4, 1},
+		},
+		{
+			name:   "catch-up with nothing new is chronological",
+			ranker: CatchUp{Since: now, Engagement: DefaultEngagement},
+			posts: []models.Post{
+				{ID: 1, CreatedAt: ago(2 * time.Hour), Likes: 9},
+				{ID: 2, CreatedAt: ago(time.Hour)},
+			},
+			want: []uint64{2, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			posts := append([]models.Post(nil), test.posts...)
+			test.ranker.Rank(posts, now)
+
+			if got := ids(posts); !equal(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEngagementScoreHalves(t *testing.T) {
+	post := models.Post{CreatedAt: ago(DefaultEngagement.HalfLife), Likes: 3}
+
+	fresh := DefaultEngagement.Score(models.Post{CreatedAt: now, Likes: 3}, now)
+	if got := DefaultEngagement.Score(post, now); got != fresh/2 {
+		t.Errorf("got %v after one half-life, want %v", got, fresh/2)
+	}
+}
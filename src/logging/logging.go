@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"devbook/src/config"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// redacted lists the headers whose values never reach the logs.
+var redacted = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+type requestIDKey struct{}
+
+// Setup makes slog write LOG_FORMAT records at LOG_LEVEL and up. The log
+// package goes through the same handler from then on.
+func Setup() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.LogLevel)); err != nil {
+		return fmt.Errorf("invalid log level %q", config.LogLevel)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch config.LogFormat {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stdout, options)
+	case "text":
+		handler = slog.NewTextHandler(os.Stdout, options)
+	default:
+		return fmt.Errorf("unknown log format %q", config.LogFormat)
+	}
+
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// NewRequestID returns a random ID for a request that came without one.
+func NewRequestID() string {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(random)
+}
+
+// ValidRequestID accepts client supplied IDs that are short and made of
+// characters that cannot break a log line.
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > 128 {
+		return false
+	}
+
+	for _, r := range requestID {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RedactHeaders returns a copy of header that is safe to log.
+func RedactHeaders(header http.Header) http.Header {
+	safe := header.Clone()
+	for _, name := range redacted {
+		if safe.Get(name) != "" {
+			safe.Set(name, "[REDACTED]")
+		}
+	}
+
+	return safe
+}
+
+// contextHandler adds the request ID carried by the context to each record,
+// so anything logged with slog's *Context functions during a request can be
+// correlated with it.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (handler contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+
+	return handler.Handler.Handle(ctx, record)
+}
+
+func (handler contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{handler.Handler.WithAttrs(attrs)}
+}
+
+func (handler contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{handler.Handler.WithGroup(name)}
+}
@@ -0,0 +1,45 @@
+package media
+
+// gifFrames counts the image descriptors of a GIF by walking its blocks
+// without decoding any pixels, so an animation's decoded size can be checked
+// before gif.DecodeAll allocates a paletted image for every frame. It stops
+// at the first malformed block; the decoder rejects those anyway.
+func gifFrames(data []byte) int {
+	if len(data) < 13 {
+		return 0
+	}
+
+	offset := 13
+	if data[10]&0x80 != 0 {
+		offset += 3 << (data[10]&0x07 + 1)
+	}
+
+	frames := 0
+	for offset < len(data) {
+		switch data[offset] {
+		case 0x21:
+			offset += 2
+		case 0x2C:
+			if offset+10 > len(data) {
+				return frames
+			}
+			frames++
+
+			flags := data[offset+9]
+			offset += 10
+			if flags&0x80 != 0 {
+				offset += 3 << (flags&0x07 + 1)
+			}
+			offset++
+		default:
+			return frames
+		}
+
+		for offset < len(data) && data[offset] != 0 {
+			offset += int(data[offset]) + 1
+		}
+		offset++
+	}
+
+	return frames
+}
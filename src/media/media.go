@@ -0,0 +1,210 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	KindImage = "image"
+	KindFile  = "file"
+
+	ThumbnailSize = 320
+	maxPixels     = 40000000
+	maxFrames     = 500
+)
+
+var ErrUnsupportedType = errors.New("unsupported media type")
+
+// allowed maps the content types accepted for upload, as sniffed from the
+// leading bytes of the file, to their kind and file extension.
+var allowed = map[string][2]string{
+	"image/jpeg":      {KindImage, ".jpg"},
+	"image/png":       {KindImage, ".png"},
+	"image/gif":       {KindImage, ".gif"},
+	"application/pdf": {KindFile, ".pdf"},
+}
+
+type File struct {
+	Kind          string
+	ContentType   string
+	Extension     string
+	Data          []byte
+	Width         int
+	Height        int
+	Thumbnail     []byte
+	ThumbnailType string
+}
+
+// Process checks what data really is by its magic bytes and, for images,
+// re-encodes it so EXIF and other metadata are dropped, applying the EXIF
+// orientation first, and renders a thumbnail.
+func Process(data []byte) (File, error) {
+	contentType := http.DetectContentType(data)
+	kind, ok := allowed[contentType]
+	if !ok {
+		return File{}, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	file := File{Kind: kind[0], ContentType: contentType, Extension: kind[1], Data: data}
+	if file.Kind != KindImage {
+		return file, nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return File{}, err
+	}
+
+	frames := 1
+	if contentType == "image/gif" {
+		frames = gifFrames(data)
+	}
+
+	if frames > maxFrames || config.Width*config.Height*frames > maxPixels {
+		return File{}, errors.New("image dimensions are too large")
+	}
+
+	var img image.Image
+	switch contentType {
+	case "image/gif":
+		img, file.Data, err = reencodeGIF(data)
+	case "image/png":
+		img, file.Data, err = reencodePNG(data)
+	default:
+		img, file.Data, err = reencodeJPEG(data)
+	}
+	if err != nil {
+		return File{}, err
+	}
+
+	bounds := img.Bounds()
+	file.Width, file.Height = bounds.Dx(), bounds.Dy()
+
+	thumbnail := thumbnail(img, ThumbnailSize)
+	var buffer bytes.Buffer
+	if contentType == "image/jpeg" {
+		file.ThumbnailType = "image/jpeg"
+		err = jpeg.Encode(&buffer, thumbnail, &jpeg.Options{Quality: 85})
+	} else {
+		file.ThumbnailType = "image/png"
+		err = png.Encode(&buffer, thumbnail)
+	}
+	if err != nil {
+		return File{}, err
+	}
+	file.Thumbnail = buffer.Bytes()
+
+	return file, nil
+}
+
+func reencodeJPEG(data []byte) (image.Image, []byte, error) {
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	img = orient(img, orientation(data))
+
+	var buffer bytes.Buffer
+	if err = jpeg.Encode(&buffer, img, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, nil, err
+	}
+
+	return img, buffer.Bytes(), nil
+}
+
+func reencodePNG(data []byte) (image.Image, []byte, error) {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buffer bytes.Buffer
+	if err = png.Encode(&buffer, img); err != nil {
+		return nil, nil, err
+	}
+
+	return img, buffer.Bytes(), nil
+}
+
+// reencodeGIF keeps every frame so animations survive, but drops comments
+// and application extensions.
+func reencodeGIF(data []byte) (image.Image, []byte, error) {
+	animation, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(animation.Image) == 0 {
+		return nil, nil, errors.New("gif has no frames")
+	}
+
+	var buffer bytes.Buffer
+	if err = gif.EncodeAll(&buffer, animation); err != nil {
+		return nil, nil, err
+	}
+
+	first := image.NewNRGBA(image.Rect(0, 0, animation.Config.Width, animation.Config.Height))
+	draw.Draw(first, animation.Image[0].Bounds(), animation.Image[0], animation.Image[0].Bounds().Min, draw.Over)
+
+	return first, buffer.Bytes(), nil
+}
+
+// thumbnail scales img down to fit in a size x size box, averaging every
+// source pixel that falls into each destination pixel.
+func thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	source := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(source, source.Bounds(), img, bounds.Min, draw.Src)
+
+	if width <= size && height <= size {
+		return source
+	}
+
+	thumbWidth, thumbHeight := size, height*size/width
+	if height > width {
+		thumbWidth, thumbHeight = width*size/height, size
+	}
+	if thumbWidth < 1 {
+		thumbWidth = 1
+	}
+	if thumbHeight < 1 {
+		thumbHeight = 1
+	}
+
+	thumb := image.NewNRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		y0, y1 := y*height/thumbHeight, (y+1)*height/thumbHeight
+		for x := 0; x < thumbWidth; x++ {
+			x0, x1 := x*width/thumbWidth, (x+1)*width/thumbWidth
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := source.Pix[sy*source.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(row[sx*4+c])
+					}
+				}
+			}
+
+			count := (y1 - y0) * (x1 - x0)
+			offset := y*thumb.Stride + x*4
+			for c := 0; c < 4; c++ {
+				thumb.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+
+	return thumb
+}
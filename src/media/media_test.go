@@ -0,0 +1,192 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+// withOrientation inserts an APP1 Exif segment carrying orientation right
+// after the SOI marker of a JPEG.
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2A\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry, 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(append(tiff, entry...), 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	header := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(segment)+2))
+
+	result := append([]byte{}, data[:2]...)
+	result = append(result, header...)
+	result = append(result, segment...)
+	return append(result, data[2:]...)
+}
+
+func encodeGIF(t *testing.T, width, height, frames int) []byte {
+	palette := color.Palette{color.Black, color.White}
+	animation := &gif.GIF{Config: image.Config{ColorModel: palette, Width: width, Height: height}}
+	for i := 0; i < frames; i++ {
+		animation.Image = append(animation.Image, image.NewPaletted(image.Rect(0, 0, 1, 1), palette))
+		animation.Delay = append(animation.Delay, 10)
+	}
+
+	var buffer bytes.Buffer
+	if err := gif.EncodeAll(&buffer, animation); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestProcessChecksMagicBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"plain text", []byte("just some text, not an image")},
+		{"executable", append([]byte("MZ\x90\x00\x03\x00\x00\x00"), make([]byte, 64)...)},
+		{"html", []byte("<!DOCTYPE html><html></html>")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Process(test.data); !errors.Is(err, ErrUnsupportedType) {
+				t.Errorf("got %v, want ErrUnsupportedType", err)
+			}
+		})
+	}
+}
+
+func TestProcessKeepsFilesAsIs(t *testing.T) {
+	data := []byte("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	file, err := Process(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if file.Kind != KindFile || file.Extension != ".pdf" || !bytes.Equal(file.Data, data) || file.Thumbnail != nil {
+		t.Errorf("got %+v, want the pdf untouched", file)
+	}
+}
+
+func TestProcessStripsEXIF(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, image.NewGray(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+	data := withOrientation(buffer.Bytes(), 6)
+
+	file, err := Process(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(file.Data, []byte("Exif")) {
+		t.Error("re-encoded image still has an Exif segment")
+	}
+
+	// Orientation 6 is a quarter turn, so the pixels come out upright.
+	if file.Width != 20 || file.Height != 40 {
+		t.Errorf("got %dx%d, want 20x40", file.Width, file.Height)
+	}
+}
+
+func TestProcessThumbnail(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		thumbWidth    int
+		thumbHeight   int
+	}{
+		{"landscape", 1000, 500, 320, 160},
+		{"portrait", 400, 800, 160, 320},
+		{"smaller than the box", 100, 50, 100, 50},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file, err := Process(encodePNG(t, test.width, test.height))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if file.Width != test.width || file.Height != test.height {
+				t.Errorf("got %dx%d, want %dx%d", file.Width, file.Height, test.width, test.height)
+			}
+
+			config, err := png.DecodeConfig(bytes.NewReader(file.Thumbnail))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if config.Width != test.thumbWidth || config.Height != test.thumbHeight {
+				t.Errorf("got a %dx%d thumbnail, want %dx%d", config.Width, config.Height, test.thumbWidth, test.thumbHeight)
+			}
+		})
+	}
+}
+
+func TestProcessGIF(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		frames        int
+		refused       bool
+	}{
+		{"small animation", 100, 100, 3, false},
+		{"frames times pixels over the limit", 1000, 1000, 41, true},
+		{"too many frames", 1, 1, maxFrames + 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := encodeGIF(t, test.width, test.height, test.frames)
+			if got := gifFrames(data); got != test.frames {
+				t.Fatalf("counted %d frames, want %d", got, test.frames)
+			}
+
+			file, err := Process(data)
+			if test.refused {
+				if err == nil {
+					t.Error("got no error, want the animation refused")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			animation, err := gif.DecodeAll(bytes.NewReader(file.Data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(animation.Image) != test.frames {
+				t.Errorf("got %d frames, want %d", len(animation.Image), test.frames)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type FollowRequest struct {
+	RequesterID uint64    `json:"requester_id"`
+	Name        string    `json:"name,omitempty"`
+	Nick        string    `json:"nick,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
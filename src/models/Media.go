@@ -0,0 +1,30 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type Media struct {
+	ID           uint64    `json:"id,omitempty"`
+	UserID       uint64    `json:"user_id,omitempty"`
+	PostID       uint64    `json:"post_id,omitempty"`
+	Kind         string    `json:"kind,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width,omitempty"`
+	Height       int       `json:"height,omitempty"`
+	URL          string    `json:"url,omitempty"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+}
+
+// Locate fills the URLs the file and its thumbnail are served from.
+func (media *Media) Locate() {
+	media.URL = fmt.Sprintf("/media/%d/content", media.ID)
+	if media.ThumbnailKey != "" {
+		media.ThumbnailURL = fmt.Sprintf("/media/%d/thumbnail", media.ID)
+	}
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+type Mention struct {
+	UserID uint64 `json:"user_id"`
+	Nick   string `json:"nick"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+}
+
+// ExtractMentions finds every @nick token in content. Offset and Length are
+// counted in characters and include the leading "@".
+func ExtractMentions(content string) []Mention {
+	var mentions []Mention
+
+	offset := 0
+	previous := ' '
+
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRuneInString(content[i:])
+
+		if r == '@' && !isNickRune(previous) && previous != '@' {
+			nick := readNick(content[i+size:])
+			if nick != "" {
+				length := utf8.RuneCountInString(nick) + 1
+				mentions = append(mentions, Mention{
+					Nick:   nick,
+					Offset: offset,
+					Length: length,
+				})
+
+				i += size + len(nick)
+				offset += length
+				previous, _ = utf8.DecodeLastRuneInString(nick)
+				continue
+			}
+		}
+
+		i += size
+		offset++
+		previous = r
+	}
+
+	return mentions
+}
+
+func readNick(s string) string {
+	end := 0
+
+	for i, r := range s {
+		if isNickRune(r) {
+			end = i + utf8.RuneLen(r)
+			continue
+		}
+
+		if r == '.' || r == '-' {
+			continue
+		}
+
+		break
+	}
+
+	return s[:end]
+}
+
+func isNickRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	NotificationFollow        = "follow"
+	NotificationFollowRequest = "follow_request"
+	NotificationLike          = "like"
+	NotificationMention       = "mention"
+	NotificationRepost        = "repost"
+	NotificationQuote         = "quote"
+)
+
+var NotificationTypes = []string{
+	NotificationFollow,
+	NotificationFollowRequest,
+	NotificationLike,
+	NotificationMention,
+	NotificationRepost,
+	NotificationQuote,
+}
+
+type Notification struct {
+	ID         uint64     `json:"id,omitempty"`
+	UserID     uint64     `json:"user_id,omitempty"`
+	Type       string     `json:"type,omitempty"`
+	PostID     uint64     `json:"post_id,omitempty"`
+	ActorID    uint64     `json:"actor_id,omitempty"`
+	ActorNick  string     `json:"actor_nick,omitempty"`
+	ActorCount uint64     `json:"actor_count,omitempty"`
+	Message    string     `json:"message,omitempty"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreatedAt  time.Time  `json:"created_at,omitempty"`
+	UpdatedAt  time.Time  `json:"updated_at,omitempty"`
+}
+
+type NotificationSettings map[string]bool
+
+// GroupKey identifies the unread notifications that repeated events of the
+// same kind are folded into, e.g. every like on one post.
+func (notification *Notification) GroupKey() string {
+	switch notification.Type {
+	case NotificationFollow, NotificationFollowRequest:
+		return notification.Type
+	case NotificationMention, NotificationQuote:
+		return fmt.Sprintf("%s:%d:%d", notification.Type, notification.PostID, notification.ActorID)
+	default:
+		return fmt.Sprintf("%s:%d", notification.Type, notification.PostID)
+	}
+}
+
+func (notification *Notification) Describe() {
+	actors := notification.ActorNick
+	if notification.ActorCount == 2 {
+		actors = fmt.Sprintf("%s and 1 other", notification.ActorNick)
+	} else if notification.ActorCount > 2 {
+		actors = fmt.Sprintf("%s and %d others", notification.ActorNick, notification.ActorCount-1)
+	}
+
+	switch notification.Type {
+	case NotificationFollow:
+		notification.Message = fmt.Sprintf("%s followed you", actors)
+	case NotificationFollowRequest:
+		notification.Message = fmt.Sprintf("%s requested to follow you", actors)
+	case NotificationLike:
+		notification.Message = fmt.Sprintf("%s liked your post", actors)
+	case NotificationMention:
+		notification.Message = fmt.Sprintf("%s mentioned you in a post", actors)
+	case NotificationRepost:
+		notification.Message = fmt.Sprintf("%s reposted your post", actors)
+	case NotificationQuote:
+		notification.Message = fmt.Sprintf("%s quoted your post", actors)
+	}
+}
+
+func (settings NotificationSettings) Validate() error {
+	return invalid(settings.validate())
+}
+
+func (settings NotificationSettings) validate() error {
+	for notificationType := range settings {
+		if !isNotificationType(notificationType) {
+			return fmt.Errorf("unknown notification type %q", notificationType)
+		}
+	}
+
+	if len(settings) == 0 {
+		return errors.New("settings cannot be empty")
+	}
+
+	return nil
+}
+
+func isNotificationType(notificationType string) bool {
+	for _, known := range NotificationTypes {
+		if known == notificationType {
+			return true
+		}
+	}
+
+	return false
+}
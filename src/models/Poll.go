@@ -0,0 +1,135 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	MinPollOptions = 2
+	MaxPollOptions = 6
+)
+
+type Poll struct {
+	Options       []PollOption `json:"options"`
+	ClosesAt      time.Time    `json:"closes_at"`
+	Multiple      bool         `json:"multiple"`
+	HideResults   bool         `json:"hide_results"`
+	Closed        bool         `json:"closed"`
+	ResultsHidden bool         `json:"results_hidden,omitempty"`
+	Voters        *uint64      `json:"voters,omitempty"`
+	MyVote        []uint64     `json:"my_vote,omitempty"`
+}
+
+type PollOption struct {
+	ID         uint64   `json:"id,omitempty"`
+	Label      string   `json:"label"`
+	Votes      *uint64  `json:"votes,omitempty"`
+	Percentage *float64 `json:"percentage,omitempty"`
+}
+
+func (poll *Poll) Prepare() error {
+	return invalid(poll.prepare())
+}
+
+func (poll *Poll) prepare() error {
+	if len(poll.Options) < MinPollOptions || len(poll.Options) > MaxPollOptions {
+		return fmt.Errorf("a poll needs between %d and %d options", MinPollOptions, MaxPollOptions)
+	}
+
+	seen := make(map[string]bool, len(poll.Options))
+	for i := range poll.Options {
+		label := strings.TrimSpace(poll.Options[i].Label)
+		if label == "" {
+			return errors.New("poll options cannot be blank")
+		}
+
+		if utf8.RuneCountInString(label) > 50 {
+			return errors.New("poll options cannot be longer than 50 characters")
+		}
+
+		if seen[strings.ToLower(label)] {
+			return fmt.Errorf("poll option %q is repeated", label)
+		}
+		seen[strings.ToLower(label)] = true
+
+		poll.Options[i] = PollOption{Label: label}
+	}
+
+	if !poll.ClosesAt.After(time.Now()) {
+		return errors.New("closes_at must be in the future")
+	}
+
+	return nil
+}
+
+// Summarize turns the raw vote counts into percentages of voters, or
+// hides them while the poll is open if the author asked for it. The
+// author always sees the results.
+func (poll *Poll) Summarize(viewerID, authorID uint64, now time.Time) {
+	poll.Closed = !now.Before(poll.ClosesAt)
+
+	if poll.HideResults && !poll.Closed && viewerID != authorID {
+		poll.ResultsHidden = true
+		poll.Voters = nil
+		for i := range poll.Options {
+			poll.Options[i].Votes = nil
+			poll.Options[i].Percentage = nil
+		}
+		return
+	}
+
+	var voters uint64
+	if poll.Voters != nil {
+		voters = *poll.Voters
+	}
+
+	for i := range poll.Options {
+		var votes uint64
+		if poll.Options[i].Votes != nil {
+			votes = *poll.Options[i].Votes
+		}
+
+		percentage := 0.0
+		if voters > 0 {
+			percentage = math.Round(float64(votes)/float64(voters)*1000) / 10
+		}
+
+		poll.Options[i].Votes = &votes
+		poll.Options[i].Percentage = &percentage
+	}
+
+	poll.Voters = &voters
+}
+
+// ValidateVote checks a ballot against the options of the poll.
+func (poll *Poll) ValidateVote(optionIDs []uint64) error {
+	return invalid(poll.validateVote(optionIDs))
+}
+
+func (poll *Poll) validateVote(optionIDs []uint64) error {
+	if len(optionIDs) == 0 {
+		return errors.New("choose at least one option")
+	}
+
+	if !poll.Multiple && len(optionIDs) > 1 {
+		return errors.New("this poll allows a single choice")
+	}
+
+	valid := make(map[uint64]bool, len(poll.Options))
+	for _, option := range poll.Options {
+		valid[option.ID] = true
+	}
+
+	for _, optionID := range optionIDs {
+		if !valid[optionID] {
+			return fmt.Errorf("option %d does not belong to this poll", optionID)
+		}
+	}
+
+	return nil
+}
@@ -6,25 +6,109 @@ import (
 	"time"
 )
 
+const (
+	VisibilityPublic    = "public"
+	VisibilityFollowers = "followers"
+	VisibilityOnlyMe    = "only-me"
+
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+
+	MaxPinnedPosts = 3
+)
+
 type Post struct {
-	ID         uint64    `json:"id,omitempty"`
-	Title      string    `json:"title,omitempty"`
-	Content    string    `json:"content,omitempty"`
-	Likes      uint64    `json:"likes"`
-	AuthorID   uint64    `json:"author_id,omitempty"`
-	AuthorNick string    `json:"author_nick,omitempty"`
-	CreatedAt  time.Time `json:"created_at,omitempty"`
+	ID               uint64     `json:"id,omitempty"`
+	Title            string     `json:"title,omitempty"`
+	Content          string     `json:"content,omitempty"`
+	Likes            uint64     `json:"likes"`
+	Visibility       string     `json:"visibility,omitempty"`
+	Status           string     `json:"status,omitempty"`
+	PublishAt        *time.Time `json:"publish_at,omitempty"`
+	AuthorID         uint64     `json:"author_id,omitempty"`
+	AuthorNick       string     `json:"author_nick,omitempty"`
+	Mentions         []Mention  `json:"mentions,omitempty"`
+	MediaIDs         []uint64   `json:"media_ids,omitempty"`
+	Media            []Media    `json:"media,omitempty"`
+	Version          uint64     `json:"version,omitempty"`
+	RevisionCount    uint64     `json:"revision_count"`
+	EditedAt         *time.Time `json:"edited_at,omitempty"`
+	RepostCount      uint64     `json:"repost_count"`
+	QuoteOfID        uint64     `json:"quote_of_id,omitempty"`
+	QuotedPost       *Post      `json:"quoted_post,omitempty"`
+	QuoteUnavailable bool       `json:"quote_unavailable,omitempty"`
+	RepostedByID     uint64     `json:"reposted_by_id,omitempty"`
+	RepostedByNick   string     `json:"reposted_by_nick,omitempty"`
+	RepostedAt       *time.Time `json:"reposted_at,omitempty"`
+	BookmarkedByMe   bool       `json:"bookmarked_by_me"`
+	Pinned           bool       `json:"pinned,omitempty"`
+	Poll             *Poll      `json:"poll,omitempty"`
+	CreatedAt        time.Time  `json:"created_at,omitempty"`
 }
 
 func (post *Post) Prepare() error {
 	if err := post.validate(); err != nil {
-		return err
+		return invalid(err)
 	}
 
 	post.format()
 	return nil
 }
 
+// EditablePost is the document PATCH requests are applied to. Every field is
+// present, empty or not, so JSON Patch can replace or test any of them.
+type EditablePost struct {
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	Visibility string     `json:"visibility"`
+	Status     string     `json:"status"`
+	PublishAt  *time.Time `json:"publish_at"`
+}
+
+func (post *Post) Editable() EditablePost {
+	return EditablePost{
+		Title:      post.Title,
+		Content:    post.Content,
+		Visibility: post.Visibility,
+		Status:     post.Status,
+		PublishAt:  post.PublishAt,
+	}
+}
+
+// Edited returns a copy of post carrying the fields of editable.
+func (post Post) Edited(editable EditablePost) Post {
+	post.Title = editable.Title
+	post.Content = editable.Content
+	post.Visibility = editable.Visibility
+	post.Status = editable.Status
+	post.PublishAt = editable.PublishAt
+	return post
+}
+
+// Changes maps the columns of the editable fields that differ in updated to
+// their new values.
+func (post *Post) Changes(updated Post) map[string]interface{} {
+	changes := make(map[string]interface{})
+
+	for column, values := range map[string][2]string{
+		"title":      {post.Title, updated.Title},
+		"content":    {post.Content, updated.Content},
+		"visibility": {post.Visibility, updated.Visibility},
+		"status":     {post.Status, updated.Status},
+	} {
+		if values[0] != values[1] {
+			changes[column] = values[1]
+		}
+	}
+
+	if !samePublishAt(post.PublishAt, updated.PublishAt) {
+		changes["publish_at"] = updated.PublishAt
+	}
+
+	return changes
+}
+
 func (post *Post) validate() error {
 	if post.Title == "" {
 		return errors.New("title is required and cannot be blank")
@@ -34,10 +118,52 @@ func (post *Post) validate() error {
 		return errors.New("content is required and cannot be blank")
 	}
 
+	switch strings.TrimSpace(post.Visibility) {
+	case "", VisibilityPublic, VisibilityFollowers, VisibilityOnlyMe:
+	default:
+		return errors.New("visibility must be public, followers or only-me")
+	}
+
+	switch strings.TrimSpace(post.Status) {
+	case "", StatusDraft, StatusPublished:
+	case StatusScheduled:
+		if post.PublishAt == nil {
+			return errors.New("publish_at is required for scheduled posts")
+		}
+
+		if !post.PublishAt.After(time.Now()) {
+			return errors.New("publish_at must be in the future")
+		}
+	default:
+		return errors.New("status must be draft, scheduled or published")
+	}
+
 	return nil
 }
 
 func (post *Post) format() {
 	post.Title = strings.TrimSpace(post.Title)
 	post.Content = strings.TrimSpace(post.Content)
+	post.Visibility = strings.TrimSpace(post.Visibility)
+
+	if post.Visibility == "" {
+		post.Visibility = VisibilityPublic
+	}
+
+	post.Status = strings.TrimSpace(post.Status)
+	if post.Status == "" {
+		post.Status = StatusPublished
+	}
+
+	if post.Status != StatusScheduled {
+		post.PublishAt = nil
+	}
+}
+
+func samePublishAt(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
 }
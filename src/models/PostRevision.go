@@ -0,0 +1,30 @@
+package models
+
+import (
+	"devbook/src/diff"
+	"time"
+)
+
+type PostRevision struct {
+	PostID    uint64    `json:"post_id,omitempty"`
+	Revision  uint64    `json:"revision"`
+	Title     string    `json:"title,omitempty"`
+	Content   string    `json:"content,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+type RevisionDiff struct {
+	From    uint64    `json:"from"`
+	To      uint64    `json:"to"`
+	Title   []diff.Op `json:"title"`
+	Content []diff.Op `json:"content"`
+}
+
+func Compare(from, to PostRevision) RevisionDiff {
+	return RevisionDiff{
+		From:    from.Revision,
+		To:      to.Revision,
+		Title:   diff.Words(from.Title, to.Title),
+		Content: diff.Words(from.Content, to.Content),
+	}
+}
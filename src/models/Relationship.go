@@ -0,0 +1,11 @@
+package models
+
+// Relationship describes how the caller relates to another user.
+type Relationship struct {
+	UserID     uint64 `json:"user_id"`
+	Following  bool   `json:"following"`
+	FollowedBy bool   `json:"followed_by"`
+	Blocked    bool   `json:"blocked"`
+	Muted      bool   `json:"muted"`
+	Pending    bool   `json:"pending"`
+}
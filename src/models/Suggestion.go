@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Suggestion is an account someone might want to follow and why.
+type Suggestion struct {
+	User       User       `json:"user"`
+	Reason     string     `json:"reason"`
+	Mutuals    int        `json:"mutuals"`
+	FollowedBy []string   `json:"followed_by,omitempty"`
+	SharedTags []string   `json:"shared_tags,omitempty"`
+	LastPostAt *time.Time `json:"last_post_at,omitempty"`
+	Score      float64    `json:"-"`
+}
+
+// Explain sets Reason from the strongest signal behind the suggestion.
+func (suggestion *Suggestion) Explain() {
+	switch {
+	case suggestion.Mutuals > 0 && len(suggestion.FollowedBy) > 0:
+		others := suggestion.Mutuals - 1
+		switch others {
+		case 0:
+			suggestion.Reason = fmt.Sprintf("Followed by %s", suggestion.FollowedBy[0])
+		case 1:
+			suggestion.Reason = fmt.Sprintf("Followed by %s and 1 other", suggestion.FollowedBy[0])
+		default:
+			suggestion.Reason = fmt.Sprintf("Followed by %s and %d others", suggestion.FollowedBy[0], others)
+		}
+	case len(suggestion.SharedTags) > 0:
+		tags := make([]string, 0, 2)
+		for _, tag := range suggestion.SharedTags {
+			if len(tags) == 2 {
+				break
+			}
+			tags = append(tags, "#"+tag)
+		}
+		suggestion.Reason = "Also posts about " + strings.Join(tags, " and ")
+	default:
+		suggestion.Reason = "Recently active"
+	}
+}
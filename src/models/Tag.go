@@ -0,0 +1,62 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const MaxTagLength = 50
+
+// ExtractTags returns the distinct #tags in content, lowercased, in the
+// order they first appear. Tags made only of digits, like "#1", are not
+// tags.
+func ExtractTags(content string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+
+	previous := ' '
+	for i := 0; i < len(content); {
+		r, size := utf8.DecodeRuneInString(content[i:])
+
+		if r == '#' && !isNickRune(previous) && previous != '#' && previous != '&' {
+			tag := readTag(content[i+size:])
+			if tag != "" {
+				i += size + len(tag)
+				previous, _ = utf8.DecodeLastRuneInString(tag)
+
+				tag = strings.ToLower(tag)
+				if hasLetter(tag) && utf8.RuneCountInString(tag) <= MaxTagLength && !seen[tag] {
+					seen[tag] = true
+					tags = append(tags, tag)
+				}
+				continue
+			}
+		}
+
+		i += size
+		previous = r
+	}
+
+	return tags
+}
+
+func readTag(s string) string {
+	for i, r := range s {
+		if !isNickRune(r) {
+			return s[:i]
+		}
+	}
+
+	return s
+}
+
+func hasLetter(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+const (
+	InteractionLike   = "like"
+	InteractionRepost = "repost"
+)
+
+// Interaction is someone other than the author liking, reposting or
+// quoting a post. Repeats by the same user are folded into their latest.
+type Interaction struct {
+	PostID    uint64
+	UserID    uint64
+	Kind      string
+	CreatedAt time.Time
+}
+
+type TrendingPost struct {
+	Post  Post    `json:"post"`
+	Score float64 `json:"score"`
+	Users int     `json:"users"`
+}
+
+type TrendingTag struct {
+	Tag   string  `json:"tag"`
+	Score float64 `json:"score"`
+	Users int     `json:"users"`
+	Posts int     `json:"posts"`
+}
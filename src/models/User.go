@@ -3,24 +3,38 @@ package models
 import (
 	"devbook/src/auth"
 	"errors"
+	"fmt"
+	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/badoux/checkmail"
 )
 
 type User struct {
-	ID        uint64    `json:"id,omitempty"`
-	Name      string    `json:"name,omitempty"`
-	Nick      string    `json:"nick,omitempty"`
-	Email     string    `json:"email,omitempty"`
-	Password  string    `json:"password,omitempty"`
-	CreatedAt time.Time `json:"created_at,omitempty"`
+	ID             uint64    `json:"id,omitempty"`
+	Name           string    `json:"name,omitempty"`
+	Nick           string    `json:"nick,omitempty"`
+	Email          string    `json:"email,omitempty"`
+	Password       string    `json:"password,omitempty"`
+	Private        bool      `json:"private"`
+	Bio            string    `json:"bio,omitempty"`
+	Website        string    `json:"website,omitempty"`
+	Location       string    `json:"location,omitempty"`
+	AvatarURL      string    `json:"avatar_url,omitempty"`
+	BannerURL      string    `json:"banner_url,omitempty"`
+	FollowersCount uint64    `json:"followers_count"`
+	FollowingCount uint64    `json:"following_count"`
+	PostsCount     uint64    `json:"posts_count"`
+	PinnedPostIDs  []uint64  `json:"pinned_post_ids,omitempty"`
+	Version        uint64    `json:"version,omitempty"`
+	CreatedAt      time.Time `json:"created_at,omitempty"`
 }
 
 func (user *User) Prepare(step string) error {
 	if err := user.validate(step); err != nil {
-		return err
+		return invalid(err)
 	}
 
 	if err := user.format(step); err != nil {
@@ -30,6 +44,72 @@ func (user *User) Prepare(step string) error {
 	return nil
 }
 
+// EditableUser is the document PATCH requests are applied to. Every field is
+// present, empty or not, so JSON Patch can replace or test any of them.
+type EditableUser struct {
+	Name      string `json:"name"`
+	Nick      string `json:"nick"`
+	Email     string `json:"email"`
+	Private   bool   `json:"private"`
+	Bio       string `json:"bio"`
+	Website   string `json:"website"`
+	Location  string `json:"location"`
+	AvatarURL string `json:"avatar_url"`
+	BannerURL string `json:"banner_url"`
+}
+
+func (user *User) Editable() EditableUser {
+	return EditableUser{
+		Name:      user.Name,
+		Nick:      user.Nick,
+		Email:     user.Email,
+		Private:   user.Private,
+		Bio:       user.Bio,
+		Website:   user.Website,
+		Location:  user.Location,
+		AvatarURL: user.AvatarURL,
+		BannerURL: user.BannerURL,
+	}
+}
+
+// Edited returns a copy of user carrying the fields of editable.
+func (user User) Edited(editable EditableUser) User {
+	user.Name = editable.Name
+	user.Nick = editable.Nick
+	user.Email = editable.Email
+	user.Private = editable.Private
+	user.Bio = editable.Bio
+	user.Website = editable.Website
+	user.Location = editable.Location
+	user.AvatarURL = editable.AvatarURL
+	user.BannerURL = editable.BannerURL
+	return user
+}
+
+// Changes maps the columns of the editable fields that differ in updated to
+// their new values.
+func (user *User) Changes(updated User) map[string]interface{} {
+	changes := make(map[string]interface{})
+
+	for column, values := range map[string][2]interface{}{
+		"name":       {user.Name, updated.Name},
+		"nick":       {user.Nick, updated.Nick},
+		"email":      {user.Email, updated.Email},
+		"private":    {user.Private, updated.Private},
+		"bio":        {user.Bio, updated.Bio},
+		"website":    {user.Website, updated.Website},
+		"location":   {user.Location, updated.Location},
+		"avatar_url": {user.AvatarURL, updated.AvatarURL},
+		"banner_url": {user.BannerURL, updated.BannerURL},
+	} {
+		if values[0] != values[1] {
+			changes[column] = values[1]
+		}
+	}
+
+	return changes
+}
+
 func (user *User) validate(step string) error {
 	if user.Name == "" {
 		return errors.New("name is required and cannot be blank")
@@ -51,6 +131,24 @@ func (user *User) validate(step string) error {
 		return errors.New("password is required and cannot be blank")
 	}
 
+	if utf8.RuneCountInString(strings.TrimSpace(user.Bio)) > 160 {
+		return errors.New("bio cannot be longer than 160 characters")
+	}
+
+	if utf8.RuneCountInString(strings.TrimSpace(user.Location)) > 50 {
+		return errors.New("location cannot be longer than 50 characters")
+	}
+
+	for field, value := range map[string]string{
+		"website":    user.Website,
+		"avatar_url": user.AvatarURL,
+		"banner_url": user.BannerURL,
+	} {
+		if err := validateURL(strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("%s %s", field, err)
+		}
+	}
+
 	return nil
 }
 
@@ -58,6 +156,11 @@ func (user *User) format(step string) error {
 	user.Name = strings.TrimSpace(user.Name)
 	user.Nick = strings.TrimSpace(user.Nick)
 	user.Email = strings.TrimSpace(user.Email)
+	user.Bio = strings.TrimSpace(user.Bio)
+	user.Website = strings.TrimSpace(user.Website)
+	user.Location = strings.TrimSpace(user.Location)
+	user.AvatarURL = strings.TrimSpace(user.AvatarURL)
+	user.BannerURL = strings.TrimSpace(user.BannerURL)
 
 	if step == "create" {
 		hash, err := auth.Hash(user.Password)
@@ -70,3 +173,20 @@ func (user *User) format(step string) error {
 
 	return nil
 }
+
+func validateURL(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if len(value) > 500 {
+		return errors.New("cannot be longer than 500 characters")
+	}
+
+	target, err := url.Parse(value)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("must be an absolute http or https url")
+	}
+
+	return nil
+}
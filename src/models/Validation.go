@@ -0,0 +1,31 @@
+package models
+
+import "errors"
+
+// ErrValidation matches, through errors.Is, every error returned when input
+// breaks the rules of a model.
+var ErrValidation = errors.New("validation failed")
+
+type validationError struct {
+	err error
+}
+
+func (err validationError) Error() string {
+	return err.err.Error()
+}
+
+func (err validationError) Unwrap() error {
+	return err.err
+}
+
+func (err validationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func invalid(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return validationError{err}
+}
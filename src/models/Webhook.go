@@ -0,0 +1,97 @@
+package models
+
+import (
+	"devbook/src/events"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var WebhookEvents = []string{
+	events.PostCreated,
+	events.PostLiked,
+	events.UserFollowed,
+}
+
+type Webhook struct {
+	ID        uint64    `json:"id,omitempty"`
+	UserID    uint64    `json:"user_id,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events,omitempty"`
+	Global    bool      `json:"global"`
+	Active    bool      `json:"active"`
+	Failures  uint64    `json:"failures"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+type WebhookDelivery struct {
+	ID         uint64    `json:"id,omitempty"`
+	WebhookID  uint64    `json:"webhook_id,omitempty"`
+	EventID    uint64    `json:"event_id"`
+	Event      string    `json:"event,omitempty"`
+	Payload    string    `json:"payload,omitempty"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+}
+
+func (webhook *Webhook) Prepare() error {
+	webhook.format()
+	return invalid(webhook.validate())
+}
+
+func (webhook *Webhook) Subscribes(event string) bool {
+	for _, subscribed := range webhook.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (webhook *Webhook) validate() error {
+	if webhook.URL == "" {
+		return errors.New("url is required and cannot be blank")
+	}
+
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return errors.New("url must be an absolute http or https url")
+	}
+
+	if len(webhook.Events) == 0 {
+		return errors.New("events is required and cannot be empty")
+	}
+
+	for _, event := range webhook.Events {
+		if !isWebhookEvent(event) {
+			return fmt.Errorf("unknown event %q", event)
+		}
+	}
+
+	return nil
+}
+
+func (webhook *Webhook) format() {
+	webhook.URL = strings.TrimSpace(webhook.URL)
+
+	for i, event := range webhook.Events {
+		webhook.Events[i] = strings.TrimSpace(event)
+	}
+}
+
+func isWebhookEvent(event string) bool {
+	for _, known := range WebhookEvents {
+		if known == event {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package netguard
+
+import (
+	"context"
+	"devbook/src/config"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress keeps requests made on behalf of users, such as
+// webhook deliveries, away from the server's own network.
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// CheckIP refuses loopback, private, link-local, multicast and unspecified
+// addresses.
+func CheckIP(ip net.IP) error {
+	if ip == nil ||
+		ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
+
+// CheckHost resolves host and refuses it if any of its addresses is not
+// public, unless WEBHOOK_ALLOW_PRIVATE is set.
+func CheckHost(ctx context.Context, host string) error {
+	if config.WebhookAllowPrivate {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return CheckIP(ip)
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return errors.New("host cannot be resolved")
+	}
+
+	for _, addr := range addrs {
+		if err = CheckIP(addr.IP); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Control checks the address a connection is about to be made to, after
+// name resolution, so a host that resolved to a public address when it was
+// registered cannot be rebound to an internal one later.
+func Control(network, address string, _ syscall.RawConn) error {
+	if config.WebhookAllowPrivate {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if err = CheckIP(net.ParseIP(host)); err != nil {
+		return fmt.Errorf("dial %s: %w", address, err)
+	}
+
+	return nil
+}
+
+// Dialer returns a dialer that only connects to public addresses.
+func Dialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{Timeout: timeout, Control: Control}
+}
@@ -0,0 +1,61 @@
+package netguard
+
+import (
+	"context"
+	"devbook/src/config"
+	"net"
+	"testing"
+)
+
+func TestCheckIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+	}
+
+	for _, test := range tests {
+		err := CheckIP(net.ParseIP(test.ip))
+		if (err == nil) != test.allowed {
+			t.Errorf("CheckIP(%s) = %v, want allowed %v", test.ip, err, test.allowed)
+		}
+	}
+}
+
+func TestCheckHost(t *testing.T) {
+	for _, host := range []string{"localhost", "127.0.0.1", "169.254.169.254", "::1"} {
+		if err := CheckHost(context.Background(), host); err == nil {
+			t.Errorf("CheckHost(%s) allowed an internal host", host)
+		}
+	}
+
+	config.WebhookAllowPrivate = true
+	defer func() { config.WebhookAllowPrivate = false }()
+
+	if err := CheckHost(context.Background(), "127.0.0.1"); err != nil {
+		t.Errorf("CheckHost(127.0.0.1) = %v with WEBHOOK_ALLOW_PRIVATE", err)
+	}
+}
+
+func TestControl(t *testing.T) {
+	if err := Control("tcp4", "127.0.0.1:8080", nil); err == nil {
+		t.Error("Control allowed a loopback address")
+	}
+
+	if err := Control("tcp4", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("Control refused a public address: %v", err)
+	}
+}
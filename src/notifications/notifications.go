@@ -0,0 +1,87 @@
+package notifications
+
+import (
+	"database/sql"
+	"devbook/src/events"
+	"devbook/src/models"
+	"devbook/src/repositories"
+)
+
+func Notify(db *sql.DB, notification models.Notification) error {
+	if notification.UserID == notification.ActorID {
+		return nil
+	}
+
+	repository := repositories.Notifications(db)
+
+	settings, err := repository.Settings(notification.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !settings[notification.Type] {
+		return nil
+	}
+
+	notification.ID, err = repository.Create(notification)
+	if err != nil {
+		return err
+	}
+
+	events.Publish(events.NotificationCreated, notification, notification.UserID)
+	return nil
+}
+
+func Follow(db *sql.DB, userID, followerID uint64) error {
+	return Notify(db, models.Notification{
+		UserID:  userID,
+		ActorID: followerID,
+		Type:    models.NotificationFollow,
+	})
+}
+
+func FollowRequest(db *sql.DB, userID, requesterID uint64) error {
+	return Notify(db, models.Notification{
+		UserID:  userID,
+		ActorID: requesterID,
+		Type:    models.NotificationFollowRequest,
+	})
+}
+
+func Like(db *sql.DB, post models.Post, likerID uint64) error {
+	return Notify(db, models.Notification{
+		UserID:  post.AuthorID,
+		ActorID: likerID,
+		Type:    models.NotificationLike,
+		PostID:  post.ID,
+	})
+}
+
+func Mention(db *sql.DB, post models.Post, userID uint64) error {
+	return Notify(db, models.Notification{
+		UserID:  userID,
+		ActorID: post.AuthorID,
+		Type:    models.NotificationMention,
+		PostID:  post.ID,
+	})
+}
+
+func Repost(db *sql.DB, post models.Post, reposterID uint64) error {
+	return Notify(db, models.Notification{
+		UserID:  post.AuthorID,
+		ActorID: reposterID,
+		Type:    models.NotificationRepost,
+		PostID:  post.ID,
+	})
+}
+
+// Quote notifies the author of the quoted post. PostID is the quote, so
+// the notification leads to what was said about the post.
+func Quote(db *sql.DB, quote models.Post, quotedAuthorID uint64) error {
+	return Notify(db, models.Notification{
+		UserID:  quotedAuthorID,
+		ActorID: quote.AuthorID,
+		Type:    models.NotificationQuote,
+		PostID:  quote.ID,
+	})
+}
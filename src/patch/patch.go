@@ -0,0 +1,391 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var ErrUnsupportedType = errors.New("unsupported patch content type")
+
+// Apply patches the JSON document original with body, interpreting body as a
+// JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) depending on
+// contentType. Plain application/json is treated as a merge patch.
+func Apply(contentType string, original, body []byte) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil && contentType != "" {
+		return nil, ErrUnsupportedType
+	}
+
+	switch mediaType {
+	case MergePatchType, "application/json", "":
+		return MergePatch(original, body)
+	case JSONPatchType:
+		return JSONPatch(original, body)
+	default:
+		return nil, ErrUnsupportedType
+	}
+}
+
+func MergePatch(original, body []byte) ([]byte, error) {
+	target, err := decode(original)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := decode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(merge(target, patch))
+}
+
+func merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+
+		targetObject[key] = merge(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+func JSONPatch(original, body []byte) ([]byte, error) {
+	document, err := decode(original)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []map[string]json.RawMessage
+	if err = json.Unmarshal(body, &operations); err != nil {
+		return nil, fmt.Errorf("json patch must be an array of operations: %v", err)
+	}
+
+	for i, operation := range operations {
+		if document, err = applyOperation(document, operation); err != nil {
+			return nil, fmt.Errorf("operation %d: %v", i, err)
+		}
+	}
+
+	return json.Marshal(document)
+}
+
+func applyOperation(document interface{}, operation map[string]json.RawMessage) (interface{}, error) {
+	var op, path string
+	if err := unmarshalMember(operation, "op", &op); err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalMember(operation, "path", &path); err != nil {
+		return nil, err
+	}
+
+	tokens, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "add", "replace", "test":
+		raw, ok := operation["value"]
+		if !ok {
+			return nil, errors.New(`member "value" is required`)
+		}
+
+		value, err := decode(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case "add":
+			return add(document, tokens, value)
+		case "replace":
+			return replace(document, tokens, value)
+		default:
+			current, err := get(document, tokens)
+			if err != nil {
+				return nil, err
+			}
+
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("test failed at %q", path)
+			}
+			return document, nil
+		}
+	case "remove":
+		document, _, err = remove(document, tokens)
+		return document, err
+	case "move", "copy":
+		var from string
+		if err = unmarshalMember(operation, "from", &from); err != nil {
+			return nil, err
+		}
+
+		fromTokens, err := parsePointer(from)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		if op == "move" {
+			if strings.HasPrefix(path, from+"/") {
+				return nil, errors.New("cannot move a value into one of its children")
+			}
+
+			if document, value, err = remove(document, fromTokens); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = get(document, fromTokens); err != nil {
+				return nil, err
+			}
+
+			if value, err = clone(value); err != nil {
+				return nil, err
+			}
+		}
+
+		return add(document, tokens, value)
+	default:
+		return nil, fmt.Errorf("unknown op %q", op)
+	}
+}
+
+func get(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch current := node.(type) {
+		case map[string]interface{}:
+			child, ok := current[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q does not exist", token)
+			}
+			node = child
+		case []interface{}:
+			index, err := arrayIndex(token, len(current)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = current[index]
+		default:
+			return nil, fmt.Errorf("path member %q does not exist", token)
+		}
+	}
+
+	return node, nil
+}
+
+func add(node interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return change(node, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch current := parent.(type) {
+		case map[string]interface{}:
+			current[key] = value
+			return current, nil
+		case []interface{}:
+			if key == "-" {
+				return append(current, value), nil
+			}
+
+			index, err := arrayIndex(key, len(current))
+			if err != nil {
+				return nil, err
+			}
+
+			current = append(current, nil)
+			copy(current[index+1:], current[index:])
+			current[index] = value
+			return current, nil
+		default:
+			return nil, fmt.Errorf("cannot add %q to a scalar value", key)
+		}
+	})
+}
+
+// replace swaps the value at tokens, which has to exist already, for value.
+func replace(node interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return change(node, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch current := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := current[key]; !ok {
+				return nil, fmt.Errorf("path member %q does not exist", key)
+			}
+
+			current[key] = value
+			return current, nil
+		case []interface{}:
+			index, err := arrayIndex(key, len(current)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			current[index] = value
+			return current, nil
+		default:
+			return nil, fmt.Errorf("path member %q does not exist", key)
+		}
+	})
+}
+
+func remove(node interface{}, tokens []string) (interface{}, interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+
+	var removed interface{}
+	node, err := change(node, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch current := parent.(type) {
+		case map[string]interface{}:
+			value, ok := current[key]
+			if !ok {
+				return nil, fmt.Errorf("path member %q does not exist", key)
+			}
+
+			removed = value
+			delete(current, key)
+			return current, nil
+		case []interface{}:
+			index, err := arrayIndex(key, len(current)-1)
+			if err != nil {
+				return nil, err
+			}
+
+			removed = current[index]
+			return append(current[:index], current[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("path member %q does not exist", key)
+		}
+	})
+
+	return node, removed, err
+}
+
+// change walks to the parent of the last token and replaces it with the
+// result of fn, rebuilding any array on the way since appends may move it.
+func change(node interface{}, tokens []string, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+
+	switch current := node.(type) {
+	case map[string]interface{}:
+		child, ok := current[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("path member %q does not exist", tokens[0])
+		}
+
+		updated, err := change(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+
+		current[tokens[0]] = updated
+		return current, nil
+	case []interface{}:
+		index, err := arrayIndex(tokens[0], len(current)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := change(current[index], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+
+		current[index] = updated
+		return current, nil
+	default:
+		return nil, fmt.Errorf("path member %q does not exist", tokens[0])
+	}
+}
+
+func arrayIndex(token string, max int) (int, error) {
+	if token != "0" && strings.HasPrefix(token, "0") {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > max {
+		return 0, fmt.Errorf("array index %q is out of range", token)
+	}
+
+	return index, nil
+}
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid json pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+func unmarshalMember(operation map[string]json.RawMessage, member string, value *string) error {
+	raw, ok := operation[member]
+	if !ok {
+		return fmt.Errorf("member %q is required", member)
+	}
+
+	return json.Unmarshal(raw, value)
+}
+
+func decode(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+func clone(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return decode(data)
+}
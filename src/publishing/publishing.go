@@ -0,0 +1,154 @@
+package publishing
+
+import (
+	"database/sql"
+	"devbook/src/config"
+	"devbook/src/database"
+	"devbook/src/events"
+	"devbook/src/models"
+	"devbook/src/notifications"
+	"devbook/src/repositories"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+const batchSize = 100
+
+// Announce tells the mentioned users, the author of a quoted post and the
+// author's audience about a post that has just been published.
+func Announce(db *sql.DB, post models.Post) error {
+	mentions, err := repositories.Mentions(db).FindByPost(post.ID)
+	if err != nil {
+		return err
+	}
+
+	if err = NotifyMentions(db, post, mentions); err != nil {
+		return err
+	}
+
+	if post.QuoteOfID != 0 {
+		quoted, err := repositories.Posts(db).FindOneById(post.QuoteOfID, post.AuthorID)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return err
+		}
+
+		if err == nil {
+			if err = notifications.Quote(db, post, quoted.AuthorID); err != nil {
+				return err
+			}
+		}
+	}
+
+	audience, err := Audience(db, post)
+	if err != nil {
+		return err
+	}
+
+	events.Publish(events.PostCreated, post, audience...)
+	return nil
+}
+
+// NotifyMentions notifies each mentioned user once, skipping the author and
+// anyone the post is not visible to.
+func NotifyMentions(db *sql.DB, post models.Post, mentions []models.Mention) error {
+	notified := map[uint64]bool{post.AuthorID: true}
+
+	posts := repositories.Posts(db)
+	for _, mention := range mentions {
+		if notified[mention.UserID] {
+			continue
+		}
+		notified[mention.UserID] = true
+
+		_, err := posts.FindOneById(post.ID, mention.UserID)
+		if errors.Is(err, repositories.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = notifications.Mention(db, post, mention.UserID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func Audience(db *sql.DB, post models.Post) ([]uint64, error) {
+	if post.Visibility == models.VisibilityOnlyMe {
+		return []uint64{post.AuthorID}, nil
+	}
+
+	followerIDs, err := repositories.Users(db).FindFollowerIDs(post.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(followerIDs, post.AuthorID), nil
+}
+
+// Start runs the scheduler that publishes due posts. Schedules live in the
+// database, so posts that fell due while the server was down go out on the
+// first run after it starts.
+func Start() error {
+	db, err := database.Connection()
+	if err != nil {
+		return err
+	}
+
+	go schedule(db, config.SchedulerInterval)
+	return nil
+}
+
+func schedule(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := PublishDue(db); err != nil {
+			slog.Error("publishing scheduled posts", "error", err)
+		}
+
+		<-ticker.C
+	}
+}
+
+// PublishDue publishes every scheduled post whose time has come.
+func PublishDue(db *sql.DB) error {
+	repository := repositories.Posts(db)
+
+	for {
+		due, err := repository.FindDue(batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, post := range due {
+			published, err := repository.Publish(post.ID)
+			if err != nil {
+				return err
+			}
+
+			// Another instance got there first.
+			if !published {
+				continue
+			}
+
+			post.Status = models.StatusPublished
+			post.PublishAt = nil
+			post.Version++
+			post.CreatedAt = time.Now()
+
+			if err = Announce(db, post); err != nil {
+				slog.Error("announcing scheduled post", "post_id", post.ID, "error", err)
+			}
+		}
+
+		if len(due) < batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"database/sql"
+	"devbook/src/models"
+)
+
+type bookmarks struct {
+	db *sql.DB
+}
+
+func Bookmarks(db *sql.DB) *bookmarks {
+	return &bookmarks{db}
+}
+
+func (repository bookmarks) Add(userID, postID uint64) error {
+	_, err := repository.db.Exec(
+		"INSERT IGNORE INTO bookmarks (user_id, post_id) VALUES (?, ?)",
+		userID, postID,
+	)
+
+	return err
+}
+
+func (repository bookmarks) Remove(userID, postID uint64) error {
+	_, err := repository.db.Exec(
+		"DELETE FROM bookmarks WHERE user_id = ? AND post_id = ?",
+		userID, postID,
+	)
+
+	return err
+}
+
+// Find lists the posts userID bookmarked, most recently saved first.
+// Bookmarks of posts the user can no longer read are skipped.
+func (repository bookmarks) Find(userID uint64, limit, offset int) ([]models.Post, error) {
+	visible, args := visibleTo(userID)
+
+	rows, err := repository.db.Query(`
+		SELECT `+postColumns+`
+		FROM bookmarks b
+		INNER JOIN posts p ON p.id = b.post_id
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE b.user_id = ? AND p.status = 'published' AND `+visible+`
+		ORDER BY b.created_at DESC, b.post_id DESC
+		LIMIT ? OFFSET ?
+		`, append(append([]interface{}{userID}, args...), limit, offset)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// FindBookmarked reports which of postIDs userID has bookmarked.
+func (repository bookmarks) FindBookmarked(userID uint64, postIDs []uint64) (map[uint64]bool, error) {
+	bookmarked := make(map[uint64]bool)
+	if len(postIDs) == 0 {
+		return bookmarked, nil
+	}
+
+	args := []interface{}{userID}
+	for _, postID := range postIDs {
+		args = append(args, postID)
+	}
+
+	rows, err := repository.db.Query(
+		"SELECT post_id FROM bookmarks WHERE user_id = ? AND post_id IN ("+placeholders(len(postIDs))+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID uint64
+		if err = rows.Scan(&postID); err != nil {
+			return nil, err
+		}
+
+		bookmarked[postID] = true
+	}
+
+	return bookmarked, nil
+}
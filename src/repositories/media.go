@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"database/sql"
+	"devbook/src/models"
+	"errors"
+)
+
+// ErrMediaUnavailable is returned when a post references media that does not
+// exist, belongs to someone else or is already attached to another post.
+var ErrMediaUnavailable = errors.New("media not found or already attached to a post")
+
+type media struct {
+	db *sql.DB
+}
+
+func Media(db *sql.DB) *media {
+	return &media{db}
+}
+
+const mediaColumns = `
+	id,
+	user_id,
+	post_id,
+	kind,
+	content_type,
+	size,
+	width,
+	height,
+	storage_key,
+	thumbnail_key,
+	created_at
+`
+
+func (repository media) Create(media models.Media) (uint64, error) {
+	statement, err := repository.db.Prepare(`
+		INSERT INTO media
+			(user_id, kind, content_type, size, width, height, storage_key, thumbnail_key)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(
+		media.UserID,
+		media.Kind,
+		media.ContentType,
+		media.Size,
+		media.Width,
+		media.Height,
+		media.StorageKey,
+		media.ThumbnailKey,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
+
+func (repository media) FindOneById(mediaID uint64) (models.Media, error) {
+	rows, err := repository.db.Query("SELECT "+mediaColumns+" FROM media WHERE id = ?", mediaID)
+	if err != nil {
+		return models.Media{}, err
+	}
+	defer rows.Close()
+
+	media, err := scanMedia(rows)
+	if err != nil || len(media) == 0 {
+		return models.Media{}, err
+	}
+
+	return media[0], nil
+}
+
+func (repository media) FindByUser(userID uint64) ([]models.Media, error) {
+	rows, err := repository.db.Query("SELECT "+mediaColumns+" FROM media WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMedia(rows)
+}
+
+func (repository media) FindByPost(postID uint64) ([]models.Media, error) {
+	media, err := repository.FindByPosts([]uint64{postID})
+	if err != nil {
+		return nil, err
+	}
+
+	return media[postID], nil
+}
+
+func (repository media) FindByPosts(postIDs []uint64) (map[uint64][]models.Media, error) {
+	media := make(map[uint64][]models.Media)
+	if len(postIDs) == 0 {
+		return media, nil
+	}
+
+	args := make([]interface{}, len(postIDs))
+	for i, postID := range postIDs {
+		args[i] = postID
+	}
+
+	rows, err := repository.db.Query(
+		"SELECT "+mediaColumns+" FROM media WHERE post_id IN ("+placeholders(len(postIDs))+") ORDER BY post_id, position",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found, err := scanMedia(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range found {
+		media[item.PostID] = append(media[item.PostID], item)
+	}
+
+	return media, nil
+}
+
+// attachMedia links the caller's unattached media to postID in the given order,
+// failing with ErrMediaUnavailable unless every ID could be claimed.
+func attachMedia(tx *sql.Tx, postID, userID uint64, mediaIDs []uint64) error {
+	if len(mediaIDs) == 0 {
+		return nil
+	}
+
+	args := []interface{}{postID}
+	for _, mediaID := range mediaIDs {
+		args = append(args, mediaID)
+	}
+	args = append(args, userID)
+	for _, mediaID := range mediaIDs {
+		args = append(args, mediaID)
+	}
+
+	result, err := tx.Exec(`
+		UPDATE media SET post_id = ?, position = FIELD(id, `+placeholders(len(mediaIDs))+`)
+		WHERE user_id = ? AND post_id IS NULL AND id IN (`+placeholders(len(mediaIDs))+`)
+		`, args...,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected != int64(len(mediaIDs)) {
+		return ErrMediaUnavailable
+	}
+
+	return nil
+}
+
+func scanMedia(rows *sql.Rows) ([]models.Media, error) {
+	var media []models.Media
+
+	for rows.Next() {
+		var item models.Media
+		var postID sql.NullInt64
+
+		if err := rows.Scan(
+			&item.ID,
+			&item.UserID,
+			&postID,
+			&item.Kind,
+			&item.ContentType,
+			&item.Size,
+			&item.Width,
+			&item.Height,
+			&item.StorageKey,
+			&item.ThumbnailKey,
+			&item.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		item.PostID = uint64(postID.Int64)
+		item.Locate()
+		media = append(media, item)
+	}
+
+	return media, nil
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"database/sql"
+	"devbook/src/models"
+)
+
+type mentions struct {
+	db *sql.DB
+}
+
+func Mentions(db *sql.DB) *mentions {
+	return &mentions{db}
+}
+
+func (repository mentions) Save(postID uint64, mentions []models.Mention) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec("DELETE FROM post_mentions WHERE post_id = ?", postID); err != nil {
+		return err
+	}
+
+	if len(mentions) > 0 {
+		statement, err := tx.Prepare(
+			"INSERT INTO post_mentions (post_id, user_id, position, length) VALUES (?, ?, ?, ?)",
+		)
+		if err != nil {
+			return err
+		}
+		defer statement.Close()
+
+		for _, mention := range mentions {
+			if _, err = statement.Exec(postID, mention.UserID, mention.Offset, mention.Length); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repository mentions) FindByPost(postID uint64) ([]models.Mention, error) {
+	mentions, err := repository.FindByPosts([]uint64{postID})
+	if err != nil {
+		return nil, err
+	}
+
+	return mentions[postID], nil
+}
+
+func (repository mentions) FindByPosts(postIDs []uint64) (map[uint64][]models.Mention, error) {
+	mentions := make(map[uint64][]models.Mention)
+	if len(postIDs) == 0 {
+		return mentions, nil
+	}
+
+	args := make([]interface{}, len(postIDs))
+	for i, postID := range postIDs {
+		args[i] = postID
+	}
+
+	rows, err := repository.db.Query(`
+		SELECT
+			m.post_id,
+			m.user_id,
+			u.nick,
+			m.position,
+			m.length
+		FROM
+			post_mentions m
+		INNER JOIN users u ON
+			u.id = m.user_id
+		WHERE
+			m.post_id IN (`+placeholders(len(postIDs))+`)
+		ORDER BY m.post_id, m.position
+		`, args...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID uint64
+		var mention models.Mention
+
+		if err = rows.Scan(
+			&postID,
+			&mention.UserID,
+			&mention.Nick,
+			&mention.Offset,
+			&mention.Length,
+		); err != nil {
+			return nil, err
+		}
+
+		mentions[postID] = append(mentions[postID], mention)
+	}
+
+	return mentions, nil
+}
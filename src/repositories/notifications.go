@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"database/sql"
+	"devbook/src/models"
+)
+
+type notifications struct {
+	db *sql.DB
+}
+
+func Notifications(db *sql.DB) *notifications {
+	return &notifications{db}
+}
+
+// Create folds the notification into the unread notification with the same
+// group key when there is one, so repeated events are delivered once. The
+// unique key on (user_id, unread_group_key) makes concurrent events for the
+// same group land on one row instead of each inserting their own.
+func (repository notifications) Create(notification models.Notification) (uint64, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var postID sql.NullInt64
+	if notification.PostID != 0 {
+		postID = sql.NullInt64{Int64: int64(notification.PostID), Valid: true}
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO notifications (user_id, type, post_id, group_key, actor_id)
+		VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)
+		`, notification.UserID, notification.Type, postID, notification.GroupKey(), notification.ActorID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	notificationID := uint64(lastInsertID)
+
+	if _, err = tx.Exec(
+		"INSERT IGNORE INTO notification_actors (notification_id, actor_id) VALUES (?, ?)",
+		notificationID, notification.ActorID,
+	); err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.Exec(`
+		UPDATE
+			notifications
+		SET
+			actor_id = ?,
+			actor_count = (
+				SELECT COUNT(*) FROM notification_actors WHERE notification_id = ?
+			),
+			updated_at = current_timestamp()
+		WHERE id = ?
+		`, notification.ActorID, notificationID, notificationID,
+	); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return notificationID, nil
+}
+
+func (repository notifications) Find(userID uint64, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	rows, err := repository.db.Query(`
+		SELECT
+			n.id,
+			n.user_id,
+			n.type,
+			COALESCE(n.post_id, 0),
+			n.actor_id,
+			u.nick,
+			n.actor_count,
+			n.read_at,
+			n.created_at,
+			n.updated_at
+		FROM
+			notifications n
+		INNER JOIN users u ON
+			u.id = n.actor_id
+		WHERE
+			n.user_id = ? AND (? = FALSE OR n.read_at IS NULL)
+		ORDER BY n.updated_at DESC, n.id DESC
+		LIMIT ? OFFSET ?
+		`, userID, unreadOnly, limit, offset,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []models.Notification
+	for rows.Next() {
+		var notification models.Notification
+		var readAt sql.NullTime
+
+		if err = rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.Type,
+			&notification.PostID,
+			&notification.ActorID,
+			&notification.ActorNick,
+			&notification.ActorCount,
+			&readAt,
+			&notification.CreatedAt,
+			&notification.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if readAt.Valid {
+			notification.ReadAt = &readAt.Time
+		}
+
+		notification.Describe()
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+func (repository notifications) MarkRead(userID, notificationID uint64) (bool, error) {
+	statement, err := repository.db.Prepare(`
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, current_timestamp())
+		WHERE id = ? AND user_id = ?
+	`)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(notificationID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (repository notifications) MarkAllRead(userID uint64) error {
+	statement, err := repository.db.Prepare(
+		"UPDATE notifications SET read_at = current_timestamp() WHERE user_id = ? AND read_at IS NULL",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(userID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository notifications) Settings(userID uint64) (models.NotificationSettings, error) {
+	settings := make(models.NotificationSettings, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		settings[notificationType] = true
+	}
+
+	rows, err := repository.db.Query(
+		"SELECT type, enabled FROM notification_settings WHERE user_id = ?",
+		userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationType string
+		var enabled bool
+
+		if err = rows.Scan(&notificationType, &enabled); err != nil {
+			return nil, err
+		}
+
+		settings[notificationType] = enabled
+	}
+
+	return settings, nil
+}
+
+func (repository notifications) SaveSettings(userID uint64, settings models.NotificationSettings) error {
+	statement, err := repository.db.Prepare(`
+		INSERT INTO notification_settings (user_id, type, enabled) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE enabled = VALUES(enabled)
+	`)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	for notificationType, enabled := range settings {
+		if _, err = statement.Exec(userID, notificationType, enabled); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package repositories
+
+import (
+	"database/sql"
+	"devbook/src/models"
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrAlreadyVoted = errors.New("you already voted in this poll")
+	ErrPollClosed   = errors.New("the poll is closed")
+)
+
+type polls struct {
+	db *sql.DB
+}
+
+func Polls(db *sql.DB) *polls {
+	return &polls{db}
+}
+
+func createPoll(tx *sql.Tx, postID uint64, poll *models.Poll) error {
+	if poll == nil {
+		return nil
+	}
+
+	if _, err := tx.Exec(
+		"INSERT INTO polls (post_id, closes_at, multiple, hide_results) VALUES (?, ?, ?, ?)",
+		postID, poll.ClosesAt, poll.Multiple, poll.HideResults,
+	); err != nil {
+		return err
+	}
+
+	for i := range poll.Options {
+		result, err := tx.Exec(
+			"INSERT INTO poll_options (post_id, position, label) VALUES (?, ?, ?)",
+			postID, i, poll.Options[i].Label,
+		)
+		if err != nil {
+			return err
+		}
+
+		optionID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		poll.Options[i].ID = uint64(optionID)
+	}
+
+	return nil
+}
+
+// FindByPosts loads the polls of postIDs with their raw vote counts and
+// the options viewerID voted for.
+func (repository polls) FindByPosts(postIDs []uint64, viewerID uint64) (map[uint64]*models.Poll, error) {
+	polls := make(map[uint64]*models.Poll)
+	if len(postIDs) == 0 {
+		return polls, nil
+	}
+
+	args := make([]interface{}, len(postIDs))
+	for i, postID := range postIDs {
+		args[i] = postID
+	}
+	in := "(" + placeholders(len(postIDs)) + ")"
+
+	rows, err := repository.db.Query(`
+		SELECT
+			p.post_id,
+			p.closes_at,
+			p.multiple,
+			p.hide_results,
+			(SELECT COUNT(*) FROM poll_ballots b WHERE b.post_id = p.post_id)
+		FROM polls p
+		WHERE p.post_id IN `+in, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID, voters uint64
+		poll := &models.Poll{Voters: new(uint64)}
+
+		if err = rows.Scan(&postID, &poll.ClosesAt, &poll.Multiple, &poll.HideResults, &voters); err != nil {
+			return nil, err
+		}
+
+		*poll.Voters = voters
+		polls[postID] = poll
+	}
+
+	if len(polls) == 0 {
+		return polls, nil
+	}
+
+	optionRows, err := repository.db.Query(`
+		SELECT
+			o.post_id,
+			o.id,
+			o.label,
+			(SELECT COUNT(*) FROM poll_votes v WHERE v.option_id = o.id)
+		FROM poll_options o
+		WHERE o.post_id IN `+in+`
+		ORDER BY o.post_id, o.position
+		`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer optionRows.Close()
+
+	for optionRows.Next() {
+		var postID, votes uint64
+		var option models.PollOption
+
+		if err = optionRows.Scan(&postID, &option.ID, &option.Label, &votes); err != nil {
+			return nil, err
+		}
+
+		option.Votes = &votes
+		polls[postID].Options = append(polls[postID].Options, option)
+	}
+
+	voteRows, err := repository.db.Query(
+		"SELECT post_id, option_id FROM poll_votes WHERE user_id = ? AND post_id IN "+in,
+		append([]interface{}{viewerID}, args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer voteRows.Close()
+
+	for voteRows.Next() {
+		var postID, optionID uint64
+		if err = voteRows.Scan(&postID, &optionID); err != nil {
+			return nil, err
+		}
+
+		polls[postID].MyVote = append(polls[postID].MyVote, optionID)
+	}
+
+	return polls, nil
+}
+
+// Vote records userID's ballot. The ballot's primary key allows one vote
+// per user and poll, whatever the number of options chosen.
+func (repository polls) Vote(postID, userID uint64, optionIDs []uint64) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var open bool
+	if err = tx.QueryRow(
+		"SELECT closes_at > current_timestamp() FROM polls WHERE post_id = ?",
+		postID,
+	).Scan(&open); err != nil {
+		return err
+	}
+
+	if !open {
+		return ErrPollClosed
+	}
+
+	_, err = tx.Exec("INSERT INTO poll_ballots (post_id, user_id) VALUES (?, ?)", postID, userID)
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == duplicateEntry {
+		return ErrAlreadyVoted
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, optionID := range optionIDs {
+		if _, err = tx.Exec(
+			"INSERT INTO poll_votes (post_id, user_id, option_id) VALUES (?, ?, ?)",
+			postID, userID, optionID,
+		); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
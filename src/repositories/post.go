@@ -3,6 +3,8 @@ package repositories
 import (
 	"database/sql"
 	"devbook/src/models"
+	"fmt"
+	"time"
 )
 
 type posts struct {
@@ -13,16 +15,65 @@ func Posts(db *sql.DB) *posts {
 	return &posts{db}
 }
 
+const postColumns = `
+	p.id,
+	p.title,
+	p.content,
+	p.likes,
+	p.visibility,
+	p.status,
+	p.publish_at,
+	p.author_id,
+	u.nick,
+	p.version,
+	p.revision_count,
+	p.edited_at,
+	(SELECT COUNT(*) FROM reposts rc WHERE rc.post_id = p.id),
+	p.quote_of_id,
+	p.created_at
+`
+
+// visibleTo restricts the posts aliased as p to the ones viewerID may read:
+// their own, public ones from public accounts, and public or followers-only
+// ones from authors that approved them as a follower. Posts of users on
+// either side of a block with the viewer are never visible, and drafts
+// and scheduled posts are only visible to their author.
+func visibleTo(viewerID uint64) (string, []interface{}) {
+	unblocked, args := notBlocked("p.author_id", viewerID)
+
+	return `(
+		(
+			p.author_id = ?
+			OR p.status = 'published' AND (
+				(
+					p.visibility IN ('public', 'followers') AND EXISTS (
+						SELECT 1 FROM followers vf
+						WHERE vf.user_id = p.author_id AND vf.follower_id = ?
+					)
+				)
+				OR (
+					p.visibility = 'public' AND NOT EXISTS (
+						SELECT 1 FROM users vu
+						WHERE vu.id = p.author_id AND vu.private
+					)
+				)
+			)
+		)
+		AND ` + unblocked + `
+	)`, append([]interface{}{viewerID, viewerID}, args...)
+}
+
 func (repository posts) Create(post models.Post) (uint64, error) {
-	statement, err := repository.db.Prepare(
-		"INSERT INTO posts (title, content, author_id) VALUES (?, ?, ?)",
-	)
+	tx, err := repository.db.Begin()
 	if err != nil {
 		return 0, err
 	}
-	defer statement.Close()
+	defer tx.Rollback()
 
-	result, err := statement.Exec(post.Title, post.Content, post.AuthorID)
+	result, err := tx.Exec(
+		"INSERT INTO posts (title, content, visibility, status, publish_at, quote_of_id, author_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		post.Title, post.Content, post.Visibility, post.Status, post.PublishAt, nullID(post.QuoteOfID), post.AuthorID,
+	)
 	if err != nil {
 		return 0, err
 	}
@@ -32,66 +83,306 @@ func (repository posts) Create(post models.Post) (uint64, error) {
 		return 0, err
 	}
 
+	if err = recordRevision(tx, uint64(lastInsertID)); err != nil {
+		return 0, err
+	}
+
+	if err = attachMedia(tx, uint64(lastInsertID), post.AuthorID, post.MediaIDs); err != nil {
+		return 0, err
+	}
+
+	if err = createPoll(tx, uint64(lastInsertID), post.Poll); err != nil {
+		return 0, err
+	}
+
+	if err = saveTags(tx, uint64(lastInsertID), post.Content); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return uint64(lastInsertID), nil
 }
 
+// Find returns the candidates for the home feed, newest first: posts by
+// the user and the accounts they follow, plus the posts those accounts
+// reposted, attributed to the reposter. A post shows up once, at its most
+// recent appearance.
 func (repository posts) Find(tokenUserID uint64) ([]models.Post, error) {
+	visible, visibleArgs := visibleTo(tokenUserID)
+	reposted, repostedArgs := repostsFor(tokenUserID)
+
+	args := []interface{}{tokenUserID, tokenUserID}
+	args = append(args, visibleArgs...)
+	args = append(args, tokenUserID)
+	args = append(args, repostedArgs...)
 
 	rows, err := repository.db.Query(`
 		SELECT
-			DISTINCT p.*,
-			u.nick
+			`+postColumns+`,
+			NULL AS reposted_by_id,
+			NULL AS reposted_by_nick,
+			p.created_at AS activity_at
 		FROM 
 			posts p
 		INNER JOIN users u ON
 			u.id = p.author_id
-		INNER JOIN followers f ON
-			p.author_id = f.user_id
 		WHERE
-			u.id = ? OR f.follower_id = ?
-		ORDER BY 1 DESC
-		`, tokenUserID, tokenUserID,
+			(
+				p.author_id = ? OR p.author_id IN (
+					SELECT f.user_id FROM followers f WHERE f.follower_id = ?
+				)
+			)
+			AND p.status = 'published' AND `+visible+`
+			AND NOT EXISTS (
+				SELECT 1 FROM mutes m
+				WHERE m.muter_id = ? AND m.muted_id = p.author_id
+			)
+		UNION ALL
+		`+reposted+`
+		ORDER BY activity_at DESC
+		`, args...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanFeed(rows)
+}
+
+// FindReposts returns the reposting half of Find: up to limit posts the
+// user and the accounts they follow reposted since the given time, newest
+// repost first.
+func (repository posts) FindReposts(tokenUserID uint64, since time.Time, limit int) ([]models.Post, error) {
+	reposted, args := repostsFor(tokenUserID)
+	args = append(args, since, limit)
+
+	rows, err := repository.db.Query(
+		reposted+" AND r.created_at >= ? ORDER BY activity_at DESC LIMIT ?",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanFeed(rows)
+}
+
+// FindTimeline loads the posts of a stored timeline that tokenUserID may
+// still read, dropping deleted ones and those of muted authors, newest
+// first.
+func (repository posts) FindTimeline(tokenUserID uint64, postIDs []uint64) ([]models.Post, error) {
+	if len(postIDs) == 0 {
+		return nil, nil
+	}
+
+	visible, visibleArgs := visibleTo(tokenUserID)
+
+	args := make([]interface{}, 0, len(postIDs)+len(visibleArgs)+1)
+	for _, postID := range postIDs {
+		args = append(args, postID)
+	}
+	args = append(args, visibleArgs...)
+	args = append(args, tokenUserID)
+
+	rows, err := repository.db.Query(`
+		SELECT `+postColumns+`
+		FROM posts p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE p.id IN (`+placeholders(len(postIDs))+`)
+			AND p.status = 'published' AND `+visible+`
+			AND NOT EXISTS (
+				SELECT 1 FROM mutes m
+				WHERE m.muter_id = ? AND m.muted_id = p.author_id
+			)
+		ORDER BY p.created_at DESC
+		`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// FindByFollowedAuthors returns the latest published posts by userID and
+// the accounts they follow, the raw material of a stored timeline. It
+// leaves out only posts nobody but their author may see; everything else
+// is checked when the timeline is read.
+func (repository posts) FindByFollowedAuthors(userID uint64, limit int) ([]models.Post, error) {
+	rows, err := repository.db.Query(`
+		SELECT `+postColumns+`
+		FROM posts p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE p.status = 'published' AND (
+			p.author_id = ? OR (
+				p.visibility <> 'only-me' AND p.author_id IN (
+					SELECT f.user_id FROM followers f WHERE f.follower_id = ?
+				)
+			)
+		)
+		ORDER BY p.created_at DESC
+		LIMIT ?
+		`, userID, userID, limit,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	return scanPosts(rows)
+}
+
+// FindLatestByAuthor returns the latest limit published posts of authorID
+// that viewerID may see, newest first.
+func (repository posts) FindLatestByAuthor(authorID, viewerID uint64, limit int) ([]models.Post, error) {
+	visible, args := visibleTo(viewerID)
+
+	rows, err := repository.db.Query(`
+		SELECT `+postColumns+`
+		FROM posts p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE p.author_id = ? AND p.status = 'published' AND `+visible+`
+		ORDER BY p.created_at DESC
+		LIMIT ?
+		`, append(append([]interface{}{authorID}, args...), limit)...,
+	)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// FindByPopularAuthors returns the latest posts of the accounts
+// tokenUserID follows that have more than maxFollowers followers. Their
+// posts are not pushed into timelines, so the feed reads them here.
+func (repository posts) FindByPopularAuthors(tokenUserID uint64, maxFollowers, limit int) ([]models.Post, error) {
+	visible, visibleArgs := visibleTo(tokenUserID)
+
+	args := []interface{}{tokenUserID, maxFollowers}
+	args = append(args, visibleArgs...)
+	args = append(args, tokenUserID, limit)
 
+	rows, err := repository.db.Query(`
+		SELECT `+postColumns+`
+		FROM posts p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE p.author_id IN (
+				SELECT f.user_id FROM followers f
+				WHERE f.follower_id = ? AND (
+					SELECT COUNT(*) FROM followers fc WHERE fc.user_id = f.user_id
+				) > ?
+			)
+			AND p.status = 'published' AND `+visible+`
+			AND NOT EXISTS (
+				SELECT 1 FROM mutes m
+				WHERE m.muter_id = ? AND m.muted_id = p.author_id
+			)
+		ORDER BY p.created_at DESC
+		LIMIT ?
+		`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
+	return scanPosts(rows)
+}
+
+// repostsFor selects the posts tokenUserID and the accounts they follow
+// reposted, with the reposter and the repost time as the last columns.
+func repostsFor(tokenUserID uint64) (string, []interface{}) {
+	visible, visibleArgs := visibleTo(tokenUserID)
+	reposterUnblocked, unblockedArgs := notBlocked("r.user_id", tokenUserID)
+
+	args := []interface{}{tokenUserID, tokenUserID}
+	args = append(args, visibleArgs...)
+	args = append(args, unblockedArgs...)
+	args = append(args, tokenUserID)
+
+	return `
+		SELECT
+			` + postColumns + `,
+			r.user_id AS reposted_by_id,
+			ru.nick AS reposted_by_nick,
+			r.created_at AS activity_at
+		FROM
+			reposts r
+		INNER JOIN posts p ON
+			p.id = r.post_id
+		INNER JOIN users u ON
+			u.id = p.author_id
+		INNER JOIN users ru ON
+			ru.id = r.user_id
+		WHERE
+			(
+				r.user_id = ? OR r.user_id IN (
+					SELECT fr.user_id FROM followers fr WHERE fr.follower_id = ?
+				)
+			)
+			AND p.status = 'published' AND ` + visible + ` AND ` + reposterUnblocked + `
+			AND NOT EXISTS (
+				SELECT 1 FROM mutes m
+				WHERE m.muter_id = ? AND m.muted_id IN (p.author_id, r.user_id)
+			)
+	`, args
+}
+
+// scanFeed reads rows of posts followed by their reposter and activity
+// time, keeping each post at its first appearance.
+func scanFeed(rows *sql.Rows) ([]models.Post, error) {
 	var posts []models.Post
+	seen := make(map[uint64]bool)
 
 	for rows.Next() {
-		var post models.Post
-
-		if err = rows.Scan(
-			&post.ID,
-			&post.Title,
-			&post.Content,
-			&post.Likes,
-			&post.AuthorID,
-			&post.AuthorNick,
-			&post.CreatedAt,
-		); err != nil {
+		var repostedByID sql.NullInt64
+		var repostedByNick sql.NullString
+		var activityAt time.Time
+
+		post, err := scanPost(rows, &repostedByID, &repostedByNick, &activityAt)
+		if err != nil {
 			return nil, err
 		}
 
+		if seen[post.ID] {
+			continue
+		}
+		seen[post.ID] = true
+
+		if repostedByID.Valid {
+			post.RepostedByID = uint64(repostedByID.Int64)
+			post.RepostedByNick = repostedByNick.String
+			post.RepostedAt = &activityAt
+		}
+
 		posts = append(posts, post)
 	}
 
 	return posts, nil
 }
 
-func (repository posts) FindOneById(postID uint64) (models.Post, error) {
+func (repository posts) FindOneById(postID, viewerID uint64) (models.Post, error) {
+	visible, args := visibleTo(viewerID)
+
 	rows, err := repository.db.Query(`
 		SELECT
-			p.*
+			`+postColumns+`
 		FROM 
 			posts p
 		INNER JOIN users u ON
 			u.id = p.author_id
-		WHERE p.id = ?
-		`, postID,
+		WHERE p.id = ? AND `+visible+`
+		`, append([]interface{}{postID}, args...)...,
 	)
 
 	if err != nil {
@@ -99,38 +390,32 @@ func (repository posts) FindOneById(postID uint64) (models.Post, error) {
 	}
 	defer rows.Close()
 
-	var post models.Post
-
-	if rows.Next() {
-		if err = rows.Scan(
-			&post.ID,
-			&post.Title,
-			&post.Content,
-			&post.Likes,
-			&post.AuthorID,
-			&post.AuthorNick,
-			&post.CreatedAt,
-		); err != nil {
-			return models.Post{}, err
-		}
+	if !rows.Next() {
+		return models.Post{}, fmt.Errorf("post %w", ErrNotFound)
 	}
 
-	return post, nil
+	return scanPost(rows)
 }
 
-func (repository posts) FindByUser(userID uint64) ([]models.Post, error) {
+// FindByUser lists a user's published posts, pinned ones first in pin
+// order.
+func (repository posts) FindByUser(userID, viewerID uint64) ([]models.Post, error) {
+	visible, args := visibleTo(viewerID)
 
 	rows, err := repository.db.Query(`
 		SELECT
-			p.*,
-			u.nick
+			`+postColumns+`,
+			pp.post_id IS NOT NULL
 		FROM 
 			posts p
 		JOIN users u ON
 			u.id = p.author_id
+		LEFT JOIN pinned_posts pp ON
+			pp.user_id = p.author_id AND pp.post_id = p.id
 		WHERE
-			p.author_id = ?
-		`, userID,
+			p.author_id = ? AND p.status = 'published' AND `+visible+`
+		ORDER BY pp.post_id IS NULL, pp.pinned_at DESC, p.id DESC
+		`, append([]interface{}{userID}, args...)...,
 	)
 
 	if err != nil {
@@ -140,93 +425,516 @@ func (repository posts) FindByUser(userID uint64) ([]models.Post, error) {
 	defer rows.Close()
 
 	var posts []models.Post
-
 	for rows.Next() {
-		var post models.Post
-
-		if err = rows.Scan(
-			&post.ID,
-			&post.Title,
-			&post.Content,
-			&post.Likes,
-			&post.AuthorID,
-			&post.AuthorNick,
-			&post.CreatedAt,
-		); err != nil {
+		var pinned bool
+
+		post, err := scanPost(rows, &pinned)
+		if err != nil {
 			return nil, err
 		}
 
+		post.Pinned = pinned
 		posts = append(posts, post)
 	}
 
 	return posts, nil
 }
 
-func (repository posts) Update(postID uint64, post models.Post) error {
-	statement, err := repository.db.Prepare(
-		"UPDATE posts SET title = ?, content = ? WHERE id = ?",
+func (repository posts) FindMentioning(userID, viewerID uint64) ([]models.Post, error) {
+	visible, args := visibleTo(viewerID)
+
+	rows, err := repository.db.Query(`
+		SELECT
+			`+postColumns+`
+		FROM
+			posts p
+		INNER JOIN users u ON
+			u.id = p.author_id
+		WHERE
+			EXISTS (
+				SELECT 1 FROM post_mentions m
+				WHERE m.post_id = p.id AND m.user_id = ?
+			) AND p.status = 'published' AND `+visible+`
+		ORDER BY p.id DESC
+		`, append([]interface{}{userID}, args...)...,
 	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+func (repository posts) Search(queryString string, viewerID uint64) ([]models.Post, error) {
+	queryString = fmt.Sprintf("%%%s%%", queryString)
+	visible, args := visibleTo(viewerID)
+
+	rows, err := repository.db.Query(`
+		SELECT
+			`+postColumns+`
+		FROM
+			posts p
+		INNER JOIN users u ON
+			u.id = p.author_id
+		WHERE
+			(p.title LIKE ? OR p.content LIKE ?) AND p.status = 'published' AND `+visible+`
+		ORDER BY p.id DESC
+		`, append([]interface{}{queryString, queryString}, args...)...,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// FindByIDs returns the published posts among postIDs that viewerID may
+// read, keyed by ID.
+func (repository posts) FindByIDs(postIDs []uint64, viewerID uint64) (map[uint64]models.Post, error) {
+	posts := make(map[uint64]models.Post)
+	if len(postIDs) == 0 {
+		return posts, nil
+	}
+
+	visible, visibleArgs := visibleTo(viewerID)
+
+	args := make([]interface{}, 0, len(postIDs)+len(visibleArgs))
+	for _, postID := range postIDs {
+		args = append(args, postID)
+	}
+	args = append(args, visibleArgs...)
+
+	rows, err := repository.db.Query(`
+		SELECT `+postColumns+`
+		FROM posts p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE p.id IN (`+placeholders(len(postIDs))+`) AND p.status = 'published' AND `+visible,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found, err := scanPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range found {
+		posts[post.ID] = post
+	}
+
+	return posts, nil
+}
+
+// FindByStatus lists the author's own posts in a given status, the next
+// scheduled ones first.
+func (repository posts) FindByStatus(authorID uint64, status string) ([]models.Post, error) {
+	rows, err := repository.db.Query(`
+		SELECT `+postColumns+`
+		FROM posts p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE p.author_id = ? AND p.status = ?
+		ORDER BY p.publish_at, p.id DESC
+		`, authorID, status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// FindDue returns scheduled posts whose publish time has passed.
+func (repository posts) FindDue(limit int) ([]models.Post, error) {
+	rows, err := repository.db.Query(`
+		SELECT `+postColumns+`
+		FROM posts p
+		INNER JOIN users u ON u.id = p.author_id
+		WHERE p.status = 'scheduled' AND p.publish_at <= current_timestamp()
+		ORDER BY p.publish_at
+		LIMIT ?
+		`, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
+
+// Publish moves a scheduled post to published, stamping it with the time it
+// went out. It reports false when the post was no longer scheduled, so
+// concurrent schedulers publish each post once.
+func (repository posts) Publish(postID uint64) (bool, error) {
+	result, err := repository.db.Exec(`
+		UPDATE posts
+		SET status = 'published', publish_at = NULL, created_at = current_timestamp(), version = version + 1
+		WHERE id = ? AND status = 'scheduled'
+		`, postID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (repository posts) Update(postID, version uint64, post models.Post) error {
+	return repository.UpdateColumns(postID, version, map[string]interface{}{
+		"title":      post.Title,
+		"content":    post.Content,
+		"visibility": post.Visibility,
+		"status":     post.Status,
+		"publish_at": post.PublishAt,
+	})
+}
+
+// UpdateColumns writes the given columns and, when the title or content
+// actually changed, records the result as a new revision in the same
+// transaction. A draft or scheduled post that becomes published is stamped
+// with the time it went out.
+func (repository posts) UpdateColumns(postID, version uint64, columns map[string]interface{}) error {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var title, content, status string
+	err = tx.QueryRow(
+		"SELECT title, content, status FROM posts WHERE id = ? AND version = ? FOR UPDATE",
+		postID, version,
+	).Scan(&title, &content, &status)
+	if err == sql.ErrNoRows {
+		return ErrVersionMismatch
+	}
 	if err != nil {
 		return err
 	}
-	defer statement.Close()
 
-	if _, err = statement.Exec(post.Title, post.Content, postID); err != nil {
+	if err = updateColumns(tx, "posts", postID, version, columns); err != nil {
 		return err
 	}
 
-	return nil
+	if value, ok := columns["status"]; ok && value == models.StatusPublished && status != models.StatusPublished {
+		if _, err = tx.Exec("UPDATE posts SET created_at = current_timestamp() WHERE id = ?", postID); err != nil {
+			return err
+		}
+	}
+
+	edited := false
+	if value, ok := columns["title"]; ok && value != title {
+		edited = true
+	}
+	if value, ok := columns["content"]; ok && value != content {
+		edited = true
+
+		if err = saveTags(tx, postID, fmt.Sprint(value)); err != nil {
+			return err
+		}
+	}
+
+	if edited {
+		// Reworking a draft is not an edit readers need to be told about.
+		if _, err = tx.Exec(
+			"UPDATE posts SET revision_count = revision_count + 1, edited_at = IF(?, current_timestamp(), edited_at) WHERE id = ?",
+			status == models.StatusPublished, postID,
+		); err != nil {
+			return err
+		}
+
+		if err = recordRevision(tx, postID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
-func (repository posts) Delete(postID uint64) error {
-	statement, err := repository.db.Prepare("DELETE FROM posts WHERE id = ?")
+func (repository posts) Delete(postID, version uint64) error {
+	statement, err := repository.db.Prepare("DELETE FROM posts WHERE id = ? AND version = ?")
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	if _, err = statement.Exec(postID); err != nil {
+	result, err := statement.Exec(postID, version)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return checkVersion(result)
 }
 
-func (repository posts) Like(postID uint64) error {
-	statement, err := repository.db.Prepare(
-		"UPDATE posts SET likes = likes + 1 WHERE id = ?",
+// Like records that userID likes postID. It reports false when they
+// already did, leaving the counter alone.
+func (repository posts) Like(postID, userID uint64) (bool, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"INSERT IGNORE INTO post_likes (post_id, user_id) VALUES (?, ?)",
+		postID, userID,
 	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if _, err = tx.Exec("UPDATE posts SET likes = likes + 1 WHERE id = ?", postID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// Unlike takes back the like of userID, if there was one.
+func (repository posts) Unlike(postID, userID uint64) error {
+	tx, err := repository.db.Begin()
 	if err != nil {
 		return err
 	}
-	defer statement.Close()
+	defer tx.Rollback()
 
-	if _, err = statement.Exec(postID); err != nil {
+	result, err := tx.Exec(
+		"DELETE FROM post_likes WHERE post_id = ? AND user_id = ?",
+		postID, userID,
+	)
+	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 
-func (repository posts) Unlike(postID uint64) error {
-	statement, err := repository.db.Prepare(`
+	if rowsAffected == 0 {
+		return nil
+	}
+
+	if _, err = tx.Exec(`
 		UPDATE 
 			posts 
 		SET 
 			likes = CASE
-						WHEN likes > 0 likes -1
+						WHEN likes > 0 THEN likes - 1
 						ELSE 0
 					END
 		WHERE id = ?
-	`)
+	`, postID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// scanPost reads the columns of postColumns followed by any extra
+// destinations selected after them.
+func scanPost(rows *sql.Rows, extra ...interface{}) (models.Post, error) {
+	var post models.Post
+	var publishAt, editedAt sql.NullTime
+	var quoteOfID sql.NullInt64
+
+	destinations := []interface{}{
+		&post.ID,
+		&post.Title,
+		&post.Content,
+		&post.Likes,
+		&post.Visibility,
+		&post.Status,
+		&publishAt,
+		&post.AuthorID,
+		&post.AuthorNick,
+		&post.Version,
+		&post.RevisionCount,
+		&editedAt,
+		&post.RepostCount,
+		&quoteOfID,
+		&post.CreatedAt,
+	}
+
+	err := rows.Scan(append(destinations, extra...)...)
+	post.QuoteOfID = uint64(quoteOfID.Int64)
+
+	if publishAt.Valid {
+		post.PublishAt = &publishAt.Time
+	}
+
+	if editedAt.Valid {
+		post.EditedAt = &editedAt.Time
+	}
+
+	return post, err
+}
+
+func scanPosts(rows *sql.Rows) ([]models.Post, error) {
+	var posts []models.Post
+
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
+
+// Repost shares postID with userID's followers. It reports false when the
+// user had already reposted it.
+func (repository posts) Repost(userID, postID uint64) (bool, error) {
+	result, err := repository.db.Exec(
+		"INSERT IGNORE INTO reposts (user_id, post_id) VALUES (?, ?)",
+		userID, postID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected == 1, nil
+}
+
+func (repository posts) Unrepost(userID, postID uint64) error {
+	_, err := repository.db.Exec(
+		"DELETE FROM reposts WHERE user_id = ? AND post_id = ?",
+		userID, postID,
+	)
+
+	return err
+}
+
+// Pin puts postID at the top of its author's profile. The user row is
+// locked while counting so concurrent pins cannot exceed MaxPinnedPosts,
+// and its version is bumped because the pins are part of the profile.
+func (repository posts) Pin(userID, postID uint64) error {
+	tx, err := repository.db.Begin()
 	if err != nil {
 		return err
 	}
-	defer statement.Close()
+	defer tx.Rollback()
+
+	if _, err = tx.Exec("SELECT id FROM users WHERE id = ? FOR UPDATE", userID); err != nil {
+		return err
+	}
 
-	if _, err = statement.Exec(postID); err != nil {
+	var pinned, alreadyPinned int
+	if err = tx.QueryRow(
+		"SELECT COUNT(*), COALESCE(SUM(post_id = ?), 0) FROM pinned_posts WHERE user_id = ?",
+		postID, userID,
+	).Scan(&pinned, &alreadyPinned); err != nil {
 		return err
 	}
 
-	return nil
+	if alreadyPinned > 0 {
+		return nil
+	}
+
+	if pinned >= models.MaxPinnedPosts {
+		return ErrTooManyPins
+	}
+
+	if _, err = tx.Exec("INSERT INTO pinned_posts (user_id, post_id) VALUES (?, ?)", userID, postID); err != nil {
+		return translate(err)
+	}
+
+	if _, err = tx.Exec("UPDATE users SET version = version + 1 WHERE id = ?", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repository posts) Unpin(userID, postID uint64) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM pinned_posts WHERE user_id = ? AND post_id = ?", userID, postID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected > 0 {
+		if _, err = tx.Exec("UPDATE users SET version = version + 1 WHERE id = ?", userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FindPinnedIDs returns the IDs of the posts userID pinned that viewerID
+// may read, most recently pinned first.
+func (repository posts) FindPinnedIDs(userID, viewerID uint64) ([]uint64, error) {
+	visible, args := visibleTo(viewerID)
+
+	rows, err := repository.db.Query(`
+		SELECT p.id
+		FROM pinned_posts pp
+		INNER JOIN posts p ON p.id = pp.post_id
+		WHERE pp.user_id = ? AND p.status = 'published' AND `+visible+`
+		ORDER BY pp.pinned_at DESC
+		`, append([]interface{}{userID}, args...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var postIDs []uint64
+	for rows.Next() {
+		var postID uint64
+		if err = rows.Scan(&postID); err != nil {
+			return nil, err
+		}
+
+		postIDs = append(postIDs, postID)
+	}
+
+	return postIDs, nil
 }
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ErrNotFound is returned when the row asked for does not exist or is not
+// visible to the viewer.
+var ErrNotFound = errors.New("not found")
+
+// ErrConflict is matched by every ConflictError.
+var ErrConflict = errors.New("already taken")
+
+// ConflictError is returned when a write would duplicate the value of a
+// unique column, or a whole row when Field is empty.
+type ConflictError struct {
+	Field string
+}
+
+func (err *ConflictError) Error() string {
+	if err.Field == "" {
+		return "it already exists"
+	}
+
+	return err.Field + " is " + ErrConflict.Error()
+}
+
+func (err *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// duplicateEntry is the MySQL error number of unique key violations.
+const duplicateEntry = 1062
+
+// translate turns driver errors callers can act on into domain errors.
+func translate(err error) error {
+	mysqlErr, ok := err.(*mysql.MySQLError)
+	if !ok || mysqlErr.Number != duplicateEntry {
+		return err
+	}
+
+	return &ConflictError{Field: duplicateKey(mysqlErr.Message)}
+}
+
+// duplicateKey reads the key name out of "Duplicate entry 'x' for key
+// 'users.nick'", which older servers report without the table name.
+// Primary keys name no single field.
+func duplicateKey(message string) string {
+	i := strings.LastIndex(message, "for key '")
+	if i < 0 {
+		return ""
+	}
+
+	key := strings.TrimSuffix(message[i+len("for key '"):], "'")
+	if j := strings.LastIndex(key, "."); j >= 0 {
+		key = key[j+1:]
+	}
+
+	if key == "PRIMARY" {
+		return ""
+	}
+
+	return key
+}
+
+// ErrVersionMismatch is returned by conditional writes when the row changed
+// since the caller read it.
+var ErrVersionMismatch = errors.New("the resource was modified by another request")
+
+// ErrTooManyPins is returned when pinning a post would exceed
+// models.MaxPinnedPosts.
+var ErrTooManyPins = errors.New("too many pinned posts, unpin one first")
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// nullID maps the zero ID to NULL for optional foreign keys.
+func nullID(id uint64) interface{} {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// updateColumns writes only the given columns of the row with id, provided
+// it is still at version. Column names must come from code, never from the
+// request.
+func updateColumns(db execer, table string, id, version uint64, columns map[string]interface{}) error {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	assignments := make([]string, len(names))
+	args := make([]interface{}, 0, len(names)+2)
+	for i, name := range names {
+		assignments[i] = name + " = ?"
+		args = append(args, columns[name])
+	}
+	args = append(args, id, version)
+
+	result, err := db.Exec(
+		"UPDATE "+table+" SET "+strings.Join(assignments, ", ")+
+			", version = version + 1 WHERE id = ? AND version = ?",
+		args...,
+	)
+	if err != nil {
+		return translate(err)
+	}
+
+	return checkVersion(result)
+}
+
+func checkVersion(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrVersionMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"database/sql"
+	"devbook/src/models"
+)
+
+type revisions struct {
+	db *sql.DB
+}
+
+func Revisions(db *sql.DB) *revisions {
+	return &revisions{db}
+}
+
+func (repository revisions) FindByPost(postID uint64) ([]models.PostRevision, error) {
+	rows, err := repository.db.Query(`
+		SELECT post_id, revision, title, content, created_at
+		FROM post_revisions
+		WHERE post_id = ?
+		ORDER BY revision DESC
+		`, postID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []models.PostRevision
+	for rows.Next() {
+		var revision models.PostRevision
+
+		if err = rows.Scan(
+			&revision.PostID,
+			&revision.Revision,
+			&revision.Title,
+			&revision.Content,
+			&revision.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
+
+// recordRevision snapshots the current title and content of postID as
+// revision number revision_count.
+func recordRevision(tx *sql.Tx, postID uint64) error {
+	_, err := tx.Exec(`
+		INSERT INTO post_revisions (post_id, revision, title, content)
+		SELECT id, revision_count, title, content FROM posts WHERE id = ?
+		`, postID,
+	)
+
+	return err
+}
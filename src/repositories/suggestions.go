@@ -0,0 +1,233 @@
+package repositories
+
+import (
+	"database/sql"
+	"devbook/src/models"
+	"strings"
+	"time"
+)
+
+type suggestions struct {
+	db *sql.DB
+}
+
+func Suggestions(db *sql.DB) *suggestions {
+	return &suggestions{db}
+}
+
+// suggestable excludes from column the user themselves and everyone they
+// follow, asked to follow, muted, dismissed or are on either side of a
+// block with.
+func suggestable(column string, userID uint64) (string, []interface{}) {
+	unblocked, args := notBlocked(column, userID)
+
+	return column + ` <> ?
+		AND NOT EXISTS (
+			SELECT 1 FROM followers sf
+			WHERE sf.user_id = ` + column + ` AND sf.follower_id = ?
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM follow_requests sr
+			WHERE sr.user_id = ` + column + ` AND sr.requester_id = ?
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM mutes sm
+			WHERE sm.muter_id = ? AND sm.muted_id = ` + column + `
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM dismissed_suggestions sd
+			WHERE sd.user_id = ? AND sd.suggested_id = ` + column + `
+		)
+		AND ` + unblocked, append([]interface{}{userID, userID, userID, userID, userID}, args...)
+}
+
+// FindByMutuals suggests the accounts followed by the accounts userID
+// follows, the most shared first, naming up to three of the accounts in
+// between.
+func (repository suggestions) FindByMutuals(userID uint64, limit int) ([]models.Suggestion, error) {
+	allowed, args := suggestable("f2.user_id", userID)
+
+	rows, err := repository.db.Query(`
+		SELECT
+			f2.user_id,
+			COUNT(*),
+			SUBSTRING_INDEX(GROUP_CONCAT(mu.nick ORDER BY mu.nick SEPARATOR ','), ',', 3)
+		FROM
+			followers f1
+		INNER JOIN followers f2 ON
+			f2.follower_id = f1.user_id
+		INNER JOIN users mu ON
+			mu.id = f1.user_id
+		WHERE
+			f1.follower_id = ? AND `+allowed+`
+		GROUP BY f2.user_id
+		ORDER BY COUNT(*) DESC
+		LIMIT ?
+		`, append(append([]interface{}{userID}, args...), limit)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []models.Suggestion
+	for rows.Next() {
+		var suggestion models.Suggestion
+		var nicks string
+
+		if err = rows.Scan(&suggestion.User.ID, &suggestion.Mutuals, &nicks); err != nil {
+			return nil, err
+		}
+
+		suggestion.FollowedBy = strings.Split(nicks, ",")
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, nil
+}
+
+// FindBySharedTags suggests the authors of public posts using the tags
+// userID used since the given time, the most tags in common first.
+func (repository suggestions) FindBySharedTags(userID uint64, since time.Time, limit int) ([]models.Suggestion, error) {
+	allowed, allowedArgs := suggestable("p.author_id", userID)
+
+	args := []interface{}{userID, since, since}
+	args = append(args, allowedArgs...)
+	args = append(args, limit)
+
+	rows, err := repository.db.Query(`
+		SELECT
+			p.author_id,
+			GROUP_CONCAT(DISTINCT t.tag ORDER BY t.tag SEPARATOR ',')
+		FROM
+			post_tags t
+		INNER JOIN posts p ON
+			p.id = t.post_id
+		INNER JOIN users a ON
+			a.id = p.author_id
+		WHERE
+			t.tag IN (
+				SELECT mt.tag FROM post_tags mt
+				INNER JOIN posts mp ON mp.id = mt.post_id
+				WHERE mp.author_id = ? AND mp.created_at >= ?
+			)
+			AND p.status = 'published' AND p.visibility = 'public' AND NOT a.private
+			AND p.created_at >= ?
+			AND `+allowed+`
+		GROUP BY p.author_id
+		ORDER BY COUNT(DISTINCT t.tag) DESC
+		LIMIT ?
+		`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []models.Suggestion
+	for rows.Next() {
+		var suggestion models.Suggestion
+		var tags string
+
+		if err = rows.Scan(&suggestion.User.ID, &tags); err != nil {
+			return nil, err
+		}
+
+		suggestion.SharedTags = strings.Split(tags, ",")
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, nil
+}
+
+// FindActive suggests the public accounts that published the most public
+// posts since the given time, for users without a network to go on.
+func (repository suggestions) FindActive(userID uint64, since time.Time, limit int) ([]models.Suggestion, error) {
+	allowed, allowedArgs := suggestable("p.author_id", userID)
+
+	args := []interface{}{since}
+	args = append(args, allowedArgs...)
+	args = append(args, limit)
+
+	rows, err := repository.db.Query(`
+		SELECT p.author_id
+		FROM posts p
+		INNER JOIN users a ON a.id = p.author_id
+		WHERE
+			p.status = 'published' AND p.visibility = 'public' AND NOT a.private
+			AND p.created_at >= ?
+			AND `+allowed+`
+		GROUP BY p.author_id
+		ORDER BY COUNT(*) DESC
+		LIMIT ?
+		`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suggestions []models.Suggestion
+	for rows.Next() {
+		var suggestion models.Suggestion
+
+		if err = rows.Scan(&suggestion.User.ID); err != nil {
+			return nil, err
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, nil
+}
+
+// FindLastPostTimes returns when each of userIDs last published a post
+// anyone may read.
+func (repository suggestions) FindLastPostTimes(userIDs []uint64) (map[uint64]time.Time, error) {
+	times := make(map[uint64]time.Time)
+	if len(userIDs) == 0 {
+		return times, nil
+	}
+
+	args := make([]interface{}, len(userIDs))
+	for i, userID := range userIDs {
+		args[i] = userID
+	}
+
+	rows, err := repository.db.Query(`
+		SELECT p.author_id, MAX(p.created_at)
+		FROM posts p
+		INNER JOIN users a ON a.id = p.author_id
+		WHERE
+			p.author_id IN (`+placeholders(len(userIDs))+`)
+			AND p.status = 'published' AND p.visibility = 'public' AND NOT a.private
+		GROUP BY p.author_id
+		`, args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID uint64
+		var lastPostAt time.Time
+
+		if err = rows.Scan(&userID, &lastPostAt); err != nil {
+			return nil, err
+		}
+
+		times[userID] = lastPostAt
+	}
+
+	return times, nil
+}
+
+func (repository suggestions) Dismiss(userID, suggestedID uint64) error {
+	_, err := repository.db.Exec(
+		"INSERT IGNORE INTO dismissed_suggestions (user_id, suggested_id) VALUES (?, ?)",
+		userID, suggestedID,
+	)
+
+	return err
+}
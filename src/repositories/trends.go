@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"database/sql"
+	"devbook/src/models"
+	"time"
+)
+
+type trends struct {
+	db *sql.DB
+}
+
+func Trends(db *sql.DB) *trends {
+	return &trends{db}
+}
+
+// FindInteractions returns the likes, reposts and quotes given since the
+// given time to public posts of public accounts. The author's own and those
+// of accounts created after joinedBefore are left out, and a user counts
+// once per post and kind, at their latest interaction.
+func (repository trends) FindInteractions(since, joinedBefore time.Time) ([]models.Interaction, error) {
+	rows, err := repository.db.Query(`
+		SELECT
+			i.post_id,
+			i.user_id,
+			i.kind,
+			MAX(i.created_at)
+		FROM (
+			SELECT post_id, user_id, 'like' AS kind, created_at
+			FROM post_likes WHERE created_at >= ?
+			UNION ALL
+			SELECT post_id, user_id, 'repost', created_at
+			FROM reposts WHERE created_at >= ?
+			UNION ALL
+			SELECT quote_of_id, author_id, 'repost', created_at
+			FROM posts WHERE quote_of_id IS NOT NULL AND status = 'published' AND created_at >= ?
+		) i
+		INNER JOIN posts p ON
+			p.id = i.post_id
+		INNER JOIN users au ON
+			au.id = p.author_id
+		INNER JOIN users iu ON
+			iu.id = i.user_id
+		WHERE
+			p.status = 'published' AND p.visibility = 'public' AND NOT au.private
+			AND i.user_id <> p.author_id
+			AND iu.created_at <= ?
+		GROUP BY i.post_id, i.user_id, i.kind
+		`, since, since, since, joinedBefore,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var interactions []models.Interaction
+	for rows.Next() {
+		var interaction models.Interaction
+
+		if err = rows.Scan(
+			&interaction.PostID,
+			&interaction.UserID,
+			&interaction.Kind,
+			&interaction.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		interactions = append(interactions, interaction)
+	}
+
+	return interactions, nil
+}
+
+// FindTags returns the tags of each of postIDs.
+func (repository trends) FindTags(postIDs []uint64) (map[uint64][]string, error) {
+	tags := make(map[uint64][]string)
+	if len(postIDs) == 0 {
+		return tags, nil
+	}
+
+	args := make([]interface{}, len(postIDs))
+	for i, postID := range postIDs {
+		args[i] = postID
+	}
+
+	rows, err := repository.db.Query(
+		"SELECT post_id, tag FROM post_tags WHERE post_id IN ("+placeholders(len(postIDs))+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID uint64
+		var tag string
+
+		if err = rows.Scan(&postID, &tag); err != nil {
+			return nil, err
+		}
+
+		tags[postID] = append(tags[postID], tag)
+	}
+
+	return tags, nil
+}
+
+// saveTags replaces the tags of postID with the ones in content.
+func saveTags(tx *sql.Tx, postID uint64, content string) error {
+	if _, err := tx.Exec("DELETE FROM post_tags WHERE post_id = ?", postID); err != nil {
+		return err
+	}
+
+	for _, tag := range models.ExtractTags(content) {
+		if _, err := tx.Exec("INSERT INTO post_tags (post_id, tag) VALUES (?, ?)", postID, tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -16,16 +16,30 @@ func Users(db *sql.DB) *users {
 
 func (repository users) Create(user models.User) (uint64, error) {
 	statement, err := repository.db.Prepare(
-		"INSERT INTO users (name, nick, email, password) VALUES (?, ?, ?, ?)",
+		`INSERT INTO users
+			(name, nick, email, password, private, bio, website, location, avatar_url, banner_url)
+		VALUES
+			(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 	)
 	if err != nil {
 		return 0, err
 	}
 	defer statement.Close()
 
-	result, err := statement.Exec(user.Name, user.Nick, user.Email, user.Password)
+	result, err := statement.Exec(
+		user.Name,
+		user.Nick,
+		user.Email,
+		user.Password,
+		user.Private,
+		user.Bio,
+		user.Website,
+		user.Location,
+		user.AvatarURL,
+		user.BannerURL,
+	)
 	if err != nil {
-		return 0, err
+		return 0, translate(err)
 	}
 
 	lastInsertID, err := result.LastInsertId()
@@ -36,21 +50,46 @@ func (repository users) Create(user models.User) (uint64, error) {
 	return uint64(lastInsertID), nil
 }
 
-func (repository users) Find(queryString string) ([]models.User, error) {
+const userColumns = `
+	u.id,
+	u.name,
+	u.nick,
+	u.email,
+	u.private,
+	u.bio,
+	u.website,
+	u.location,
+	u.avatar_url,
+	u.banner_url,
+	(SELECT COUNT(*) FROM followers cf WHERE cf.user_id = u.id),
+	(SELECT COUNT(*) FROM followers cf WHERE cf.follower_id = u.id),
+	(SELECT COUNT(*) FROM posts cp WHERE cp.author_id = u.id AND cp.status = 'published'),
+	u.version,
+	u.created_at
+`
+
+// notBlocked excludes the users in column that viewerID blocked or was
+// blocked by.
+func notBlocked(column string, viewerID uint64) (string, []interface{}) {
+	return `NOT EXISTS (
+		SELECT 1 FROM blocks nb
+		WHERE (nb.blocker_id = ? AND nb.blocked_id = ` + column + `)
+		OR (nb.blocker_id = ` + column + ` AND nb.blocked_id = ?)
+	)`, []interface{}{viewerID, viewerID}
+}
+
+func (repository users) Find(queryString string, viewerID uint64) ([]models.User, error) {
 	queryString = fmt.Sprintf("%%%s%%", queryString)
+	unblocked, args := notBlocked("u.id", viewerID)
 
 	rows, err := repository.db.Query(`
 		SELECT
-			id,
-			name,
-			nick,
-			email,
-			created_at
+			`+userColumns+`
 		FROM 
-			users
+			users u
 		WHERE
-			name LIKE ? OR nick LIKE ?
-		`, queryString, queryString,
+			(u.name LIKE ? OR u.nick LIKE ?) AND `+unblocked+`
+		`, append([]interface{}{queryString, queryString}, args...)...,
 	)
 
 	if err != nil {
@@ -59,53 +98,118 @@ func (repository users) Find(queryString string) ([]models.User, error) {
 
 	defer rows.Close()
 
-	var users []models.User
+	return scanUsers(rows)
+}
+
+// FindOneById returns userID unless they and viewerID blocked one another.
+func (repository users) FindOneById(userID, viewerID uint64) (models.User, error) {
+	unblocked, args := notBlocked("u.id", viewerID)
+
+	rows, err := repository.db.Query(
+		"SELECT "+userColumns+" FROM users u WHERE u.id = ? AND "+unblocked,
+		append([]interface{}{userID}, args...)...,
+	)
+
+	if err != nil {
+		return models.User{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.User{}, fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	return scanUser(rows)
+}
+
+func (repository users) FindOneByNick(nick string, viewerID uint64) (models.User, error) {
+	unblocked, args := notBlocked("u.id", viewerID)
+
+	rows, err := repository.db.Query(
+		"SELECT "+userColumns+" FROM users u WHERE u.nick = ? AND "+unblocked,
+		append([]interface{}{nick}, args...)...,
+	)
+
+	if err != nil {
+		return models.User{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return models.User{}, fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	return scanUser(rows)
+}
+
+func (repository users) FindByNicks(nicks []string, viewerID uint64) ([]models.User, error) {
+	if len(nicks) == 0 {
+		return nil, nil
+	}
+
+	unblocked, args := notBlocked("u.id", viewerID)
+	for _, nick := range nicks {
+		args = append(args, nick)
+	}
+
+	rows, err := repository.db.Query(
+		"SELECT u.id, u.nick FROM users u WHERE "+unblocked+" AND u.nick IN ("+placeholders(len(nicks))+")",
+		args...,
+	)
 
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
 	for rows.Next() {
 		var user models.User
 
 		if err = rows.Scan(
 			&user.ID,
-			&user.Name,
 			&user.Nick,
-			user.Email,
-			user.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
-
 		users = append(users, user)
 	}
 
 	return users, nil
 }
 
-func (repository users) FindOneById(userID uint64) (models.User, error) {
+// FindByIDs returns the users among userIDs that viewerID did not block
+// and was not blocked by, keyed by ID.
+func (repository users) FindByIDs(userIDs []uint64, viewerID uint64) (map[uint64]models.User, error) {
+	users := make(map[uint64]models.User)
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+
+	unblocked, args := notBlocked("u.id", viewerID)
+	for _, userID := range userIDs {
+		args = append(args, userID)
+	}
+
 	rows, err := repository.db.Query(
-		"SELECT id, name, nick, email, created_at FROM users WHERE id = ?",
-		userID,
+		"SELECT "+userColumns+" FROM users u WHERE "+unblocked+" AND u.id IN ("+placeholders(len(userIDs))+")",
+		args...,
 	)
-
 	if err != nil {
-		return models.User{}, err
+		return nil, err
 	}
 	defer rows.Close()
 
-	var user models.User
+	found, err := scanUsers(rows)
+	if err != nil {
+		return nil, err
+	}
 
-	if rows.Next() {
-		if err = rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Nick,
-			&user.Email,
-			&user.CreatedAt,
-		); err != nil {
-			return models.User{}, err
-		}
+	for _, user := range found {
+		users[user.ID] = user
 	}
 
-	return user, nil
+	return users, nil
 }
 
 func (repository users) FindOneByEmail(email string) (models.User, error) {
@@ -133,6 +237,17 @@ func (repository users) FindOneByEmail(email string) (models.User, error) {
 	return user, nil
 }
 
+func (repository users) IsAdmin(userID uint64) (bool, error) {
+	var admin bool
+
+	err := repository.db.QueryRow("SELECT admin FROM users WHERE id = ?", userID).Scan(&admin)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	return admin, err
+}
+
 func (repository users) FindCurrentPasswordById(userID uint64) (string, error) {
 	rows, err := repository.db.Query(
 		"SELECT password FROM users WHERE id = ?",
@@ -157,34 +272,56 @@ func (repository users) FindCurrentPasswordById(userID uint64) (string, error) {
 	return user.Password, nil
 }
 
-func (repository users) Update(userID uint64, user models.User) error {
+func (repository users) Update(userID, version uint64, user models.User) error {
 	statement, err := repository.db.Prepare(
-		"UPDATE users SET name = ?, nick = ?, email = ? WHERE id = ?",
+		`UPDATE users SET
+			name = ?, nick = ?, email = ?, private = ?,
+			bio = ?, website = ?, location = ?, avatar_url = ?, banner_url = ?,
+			version = version + 1
+		WHERE id = ? AND version = ?`,
 	)
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	if _, err = statement.Exec(user.Name, user.Nick, user.Email, userID); err != nil {
-		return err
+	result, err := statement.Exec(
+		user.Name,
+		user.Nick,
+		user.Email,
+		user.Private,
+		user.Bio,
+		user.Website,
+		user.Location,
+		user.AvatarURL,
+		user.BannerURL,
+		userID,
+		version,
+	)
+	if err != nil {
+		return translate(err)
 	}
 
-	return nil
+	return checkVersion(result)
 }
 
-func (repository users) Delete(userID uint64) error {
-	statement, err := repository.db.Prepare("DELETE FROM users WHERE id = ?")
+func (repository users) UpdateColumns(userID, version uint64, columns map[string]interface{}) error {
+	return updateColumns(repository.db, "users", userID, version, columns)
+}
+
+func (repository users) Delete(userID, version uint64) error {
+	statement, err := repository.db.Prepare("DELETE FROM users WHERE id = ? AND version = ?")
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	if _, err = statement.Exec(userID); err != nil {
+	result, err := statement.Exec(userID, version)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return checkVersion(result)
 }
 
 func (repository users) UpdatePassword(userID uint64, newHashPassword string) error {
@@ -213,84 +350,420 @@ func (repository users) Follow(userID, followID uint64) error {
 	defer statement.Close()
 
 	if _, err = statement.Exec(userID, followID); err != nil {
-		return err
+		return translate(err)
 	}
 
 	return nil
 }
 
 func (repository users) Unfollow(userID, followID uint64) error {
-	statement, err := repository.db.Prepare(
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(
 		"DELETE FROM followers WHERE user_id = ? AND follower_id = ?",
+		userID, followID,
+	); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(
+		"DELETE FROM follow_requests WHERE user_id = ? AND requester_id = ?",
+		userID, followID,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repository users) IsFollowing(userID, followID uint64) (bool, error) {
+	var following bool
+
+	err := repository.db.QueryRow(
+		"SELECT EXISTS (SELECT 1 FROM followers WHERE user_id = ? AND follower_id = ?)",
+		userID, followID,
+	).Scan(&following)
+
+	return following, err
+}
+
+func (repository users) RequestFollow(userID, requesterID uint64) error {
+	statement, err := repository.db.Prepare(
+		"INSERT IGNORE INTO follow_requests (user_id, requester_id) VALUES (?, ?)",
 	)
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
-	if _, err = statement.Exec(userID, followID); err != nil {
+	if _, err = statement.Exec(userID, requesterID); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (repository users) GetFollowers(userID uint64) ([]models.User, error) {
+func (repository users) GetFollowRequests(userID uint64) ([]models.FollowRequest, error) {
+	unblocked, args := notBlocked("u.id", userID)
+
 	rows, err := repository.db.Query(`
 		SELECT
 			u.id,
 			u.name,
 			u.nick,
-			u.email,
-			u.created_at
+			r.created_at
+		FROM
+			follow_requests r
+		INNER JOIN users u ON
+			u.id = r.requester_id
+		WHERE
+			r.user_id = ? AND `+unblocked+`
+		ORDER BY r.created_at
+	`, append([]interface{}{userID}, args...)...)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []models.FollowRequest
+	for rows.Next() {
+		var request models.FollowRequest
+
+		if err = rows.Scan(
+			&request.RequesterID,
+			&request.Name,
+			&request.Nick,
+			&request.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		requests = append(requests, request)
+	}
+
+	return requests, nil
+}
+
+// ApproveFollowRequest turns a pending request into a follow. It reports
+// false when there was no such request.
+func (repository users) ApproveFollowRequest(userID, requesterID uint64) (bool, error) {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(
+		"DELETE FROM follow_requests WHERE user_id = ? AND requester_id = ?",
+		userID, requesterID,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil || rowsAffected == 0 {
+		return false, err
+	}
+
+	if _, err = tx.Exec(
+		"INSERT IGNORE INTO followers (user_id, follower_id) VALUES (?, ?)",
+		userID, requesterID,
+	); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (repository users) RejectFollowRequest(userID, requesterID uint64) (bool, error) {
+	statement, err := repository.db.Prepare(
+		"DELETE FROM follow_requests WHERE user_id = ? AND requester_id = ?",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(userID, requesterID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (repository users) GetFollowers(userID, viewerID uint64) ([]models.User, error) {
+	unblocked, args := notBlocked("u.id", viewerID)
+
+	rows, err := repository.db.Query(`
+		SELECT
+			`+userColumns+`
 		FROM 
 			users u
 		INNER JOIN followers f ON
 			u.id = f.follower_id
 		WHERE
-			f.user_id = ?
-	`, userID)
+			f.user_id = ? AND `+unblocked+`
+	`, append([]interface{}{userID}, args...)...)
 
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []models.User
+	return scanUsers(rows)
+}
+
+func (repository users) FindFollowerIDs(userID uint64) ([]uint64, error) {
+	rows, err := repository.db.Query(
+		"SELECT follower_id FROM followers WHERE user_id = ?",
+		userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var followerIDs []uint64
 	for rows.Next() {
-		var user models.User
+		var followerID uint64
+
+		if err = rows.Scan(&followerID); err != nil {
+			return nil, err
+		}
+		followerIDs = append(followerIDs, followerID)
+	}
+
+	return followerIDs, nil
+}
+
+func (repository users) GetFollowing(userID, viewerID uint64) ([]models.User, error) {
+	unblocked, args := notBlocked("u.id", viewerID)
+
+	rows, err := repository.db.Query(`
+		SELECT
+			`+userColumns+`
+		FROM 
+			users u
+		INNER JOIN followers f ON
+			u.id = f.user_id
+		WHERE
+			f.follower_id = ? AND `+unblocked+`
+	`, append([]interface{}{userID}, args...)...)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsers(rows)
+}
+
+// GetMutuals lists the accounts followed by both userID and viewerID.
+func (repository users) GetMutuals(userID, viewerID uint64) ([]models.User, error) {
+	unblocked, args := notBlocked("u.id", viewerID)
+
+	rows, err := repository.db.Query(`
+		SELECT
+			`+userColumns+`
+		FROM 
+			users u
+		INNER JOIN followers fu ON
+			fu.user_id = u.id AND fu.follower_id = ?
+		INNER JOIN followers fv ON
+			fv.user_id = u.id AND fv.follower_id = ?
+		WHERE
+			`+unblocked+`
+	`, append([]interface{}{userID, viewerID}, args...)...)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanUsers(rows)
+}
+
+// FindRelationships returns how viewerID relates to each of userIDs that
+// exists, keyed by ID. Users who blocked viewerID are left out, as if they
+// did not exist; the ones viewerID blocked are kept so the block shows.
+func (repository users) FindRelationships(viewerID uint64, userIDs []uint64) (map[uint64]models.Relationship, error) {
+	relationships := make(map[uint64]models.Relationship)
+	if len(userIDs) == 0 {
+		return relationships, nil
+	}
+
+	args := []interface{}{viewerID, viewerID, viewerID, viewerID, viewerID, viewerID}
+	for _, userID := range userIDs {
+		args = append(args, userID)
+	}
+
+	rows, err := repository.db.Query(`
+		SELECT
+			u.id,
+			EXISTS (SELECT 1 FROM followers f WHERE f.user_id = u.id AND f.follower_id = ?),
+			EXISTS (SELECT 1 FROM followers f WHERE f.user_id = ? AND f.follower_id = u.id),
+			EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = ? AND b.blocked_id = u.id),
+			EXISTS (SELECT 1 FROM mutes m WHERE m.muter_id = ? AND m.muted_id = u.id),
+			EXISTS (SELECT 1 FROM follow_requests r WHERE r.user_id = u.id AND r.requester_id = ?)
+		FROM
+			users u
+		WHERE
+			NOT EXISTS (SELECT 1 FROM blocks nb WHERE nb.blocker_id = u.id AND nb.blocked_id = ?)
+			AND u.id IN (`+placeholders(len(userIDs))+`)
+	`, args...)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var relationship models.Relationship
 
 		if err = rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Nick,
-			&user.Email,
-			&user.CreatedAt,
+			&relationship.UserID,
+			&relationship.Following,
+			&relationship.FollowedBy,
+			&relationship.Blocked,
+			&relationship.Muted,
+			&relationship.Pending,
 		); err != nil {
 			return nil, err
 		}
-		users = append(users, user)
+
+		relationships[relationship.UserID] = relationship
 	}
 
-	return users, nil
+	return relationships, nil
 }
 
-func (repository users) GetFollowing(userID uint64) ([]models.User, error) {
-	rows, err := repository.db.Query(`
+func (repository users) Block(blockerID, blockedID uint64) error {
+	tx, err := repository.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.Exec(
+		"INSERT IGNORE INTO blocks (blocker_id, blocked_id) VALUES (?, ?)",
+		blockerID, blockedID,
+	); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`
+		DELETE FROM followers
+		WHERE (user_id = ? AND follower_id = ?) OR (user_id = ? AND follower_id = ?)
+		`, blockerID, blockedID, blockedID, blockerID,
+	); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`
+		DELETE FROM follow_requests
+		WHERE (user_id = ? AND requester_id = ?) OR (user_id = ? AND requester_id = ?)
+		`, blockerID, blockedID, blockedID, blockerID,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (repository users) Unblock(blockerID, blockedID uint64) error {
+	statement, err := repository.db.Prepare(
+		"DELETE FROM blocks WHERE blocker_id = ? AND blocked_id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(blockerID, blockedID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository users) Mute(muterID, mutedID uint64) error {
+	statement, err := repository.db.Prepare(
+		"INSERT IGNORE INTO mutes (muter_id, muted_id) VALUES (?, ?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(muterID, mutedID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository users) Unmute(muterID, mutedID uint64) error {
+	statement, err := repository.db.Prepare(
+		"DELETE FROM mutes WHERE muter_id = ? AND muted_id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(muterID, mutedID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository users) GetBlocked(userID uint64) ([]models.User, error) {
+	return repository.findRelated(`
 		SELECT
 			u.id,
 			u.name,
-			u.nick,
-			u.email,
-			u.created_at
-		FROM 
+			u.nick
+		FROM
 			users u
-		INNER JOIN followers f ON
-			u.id = f.follower_id
+		INNER JOIN blocks b ON
+			u.id = b.blocked_id
+		WHERE
+			b.blocker_id = ?
+		ORDER BY b.created_at DESC
+	`, userID)
+}
+
+func (repository users) GetMuted(userID uint64) ([]models.User, error) {
+	return repository.findRelated(`
+		SELECT
+			u.id,
+			u.name,
+			u.nick
+		FROM
+			users u
+		INNER JOIN mutes m ON
+			u.id = m.muted_id
 		WHERE
-			f.follower_id = ?
+			m.muter_id = ?
+		ORDER BY m.created_at DESC
 	`, userID)
+}
 
+func (repository users) findRelated(query string, userID uint64) ([]models.User, error) {
+	rows, err := repository.db.Query(query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -304,8 +777,6 @@ func (repository users) GetFollowing(userID uint64) ([]models.User, error) {
 			&user.ID,
 			&user.Name,
 			&user.Nick,
-			&user.Email,
-			&user.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -314,3 +785,42 @@ func (repository users) GetFollowing(userID uint64) ([]models.User, error) {
 
 	return users, nil
 }
+
+func scanUser(rows *sql.Rows) (models.User, error) {
+	var user models.User
+
+	err := rows.Scan(
+		&user.ID,
+		&user.Name,
+		&user.Nick,
+		&user.Email,
+		&user.Private,
+		&user.Bio,
+		&user.Website,
+		&user.Location,
+		&user.AvatarURL,
+		&user.BannerURL,
+		&user.FollowersCount,
+		&user.FollowingCount,
+		&user.PostsCount,
+		&user.Version,
+		&user.CreatedAt,
+	)
+
+	return user, err
+}
+
+func scanUsers(rows *sql.Rows) ([]models.User, error) {
+	var users []models.User
+
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,271 @@
+package repositories
+
+import (
+	"database/sql"
+	"devbook/src/models"
+	"fmt"
+	"strings"
+)
+
+type webhooks struct {
+	db *sql.DB
+}
+
+func Webhooks(db *sql.DB) *webhooks {
+	return &webhooks{db}
+}
+
+const webhookColumns = `
+	id,
+	user_id,
+	url,
+	secret,
+	events,
+	global,
+	active,
+	failures,
+	created_at
+`
+
+func (repository webhooks) Create(webhook models.Webhook) (uint64, error) {
+	statement, err := repository.db.Prepare(
+		"INSERT INTO webhooks (user_id, url, secret, events, global) VALUES (?, ?, ?, ?, ?)",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(
+		webhook.UserID,
+		webhook.URL,
+		webhook.Secret,
+		strings.Join(webhook.Events, ","),
+		webhook.Global,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
+
+func (repository webhooks) FindByUser(userID uint64) ([]models.Webhook, error) {
+	rows, err := repository.db.Query(
+		"SELECT "+webhookColumns+" FROM webhooks WHERE user_id = ? ORDER BY id",
+		userID,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWebhooks(rows)
+}
+
+func (repository webhooks) FindActive(event string) ([]models.Webhook, error) {
+	rows, err := repository.db.Query(
+		"SELECT "+webhookColumns+" FROM webhooks WHERE active AND FIND_IN_SET(?, events) > 0",
+		event,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWebhooks(rows)
+}
+
+func (repository webhooks) FindOneById(webhookID uint64) (models.Webhook, error) {
+	rows, err := repository.db.Query(
+		"SELECT "+webhookColumns+" FROM webhooks WHERE id = ?",
+		webhookID,
+	)
+
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	defer rows.Close()
+
+	webhooks, err := scanWebhooks(rows)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	if len(webhooks) == 0 {
+		return models.Webhook{}, fmt.Errorf("webhook %w", ErrNotFound)
+	}
+
+	return webhooks[0], nil
+}
+
+func (repository webhooks) Delete(webhookID uint64) error {
+	statement, err := repository.db.Prepare("DELETE FROM webhooks WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(webhookID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RecordFailure counts a delivery that ran out of attempts and disables the
+// webhook once maxFailures consecutive deliveries have failed.
+func (repository webhooks) RecordFailure(webhookID uint64, maxFailures int) error {
+	statement, err := repository.db.Prepare(`
+		UPDATE
+			webhooks
+		SET
+			failures = failures + 1,
+			active = failures < ?
+		WHERE id = ?
+	`)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(maxFailures, webhookID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository webhooks) ResetFailures(webhookID uint64) error {
+	statement, err := repository.db.Prepare("UPDATE webhooks SET failures = 0 WHERE id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	if _, err = statement.Exec(webhookID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (repository webhooks) CreateDelivery(delivery models.WebhookDelivery) (uint64, error) {
+	statement, err := repository.db.Prepare(`
+		INSERT INTO webhook_deliveries
+			(webhook_id, event_id, event, payload, attempt, status_code, success, error)
+		VALUES
+			(?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	result, err := statement.Exec(
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.Event,
+		delivery.Payload,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.Success,
+		delivery.Error,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(lastInsertID), nil
+}
+
+func (repository webhooks) FindDeliveries(webhookID uint64, limit, offset int) ([]models.WebhookDelivery, error) {
+	rows, err := repository.db.Query(`
+		SELECT
+			id,
+			webhook_id,
+			event_id,
+			event,
+			payload,
+			attempt,
+			status_code,
+			success,
+			error,
+			created_at
+		FROM
+			webhook_deliveries
+		WHERE
+			webhook_id = ?
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+		`, webhookID, limit, offset,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+
+		if err = rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.EventID,
+			&delivery.Event,
+			&delivery.Payload,
+			&delivery.Attempt,
+			&delivery.StatusCode,
+			&delivery.Success,
+			&delivery.Error,
+			&delivery.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func scanWebhooks(rows *sql.Rows) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+
+	for rows.Next() {
+		var webhook models.Webhook
+		var events string
+
+		if err := rows.Scan(
+			&webhook.ID,
+			&webhook.UserID,
+			&webhook.URL,
+			&webhook.Secret,
+			&events,
+			&webhook.Global,
+			&webhook.Active,
+			&webhook.Failures,
+			&webhook.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		webhook.Events = strings.Split(events, ",")
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
@@ -0,0 +1,81 @@
+package response
+
+import "net/http"
+
+const ProblemContentType = "application/problem+json"
+
+// Stable codes clients can branch on. Messages may change; these do not.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeUnprocessableEntity  = "unprocessable_entity"
+	CodePreconditionRequired = "precondition_required"
+	CodeTooManyRequests      = "too_many_requests"
+	CodeInternal             = "internal_error"
+	CodeUnavailable          = "service_unavailable"
+
+	CodeValidation       = "validation_failed"
+	CodeVersionMismatch  = "version_mismatch"
+	CodeTooManyPins      = "too_many_pins"
+	CodeAlreadyVoted     = "already_voted"
+	CodePollClosed       = "poll_closed"
+	CodeMediaUnavailable = "media_unavailable"
+)
+
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeBadRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusMethodNotAllowed:      CodeMethodNotAllowed,
+	http.StatusConflict:              CodeConflict,
+	http.StatusPreconditionFailed:    CodePreconditionFailed,
+	http.StatusRequestEntityTooLarge: CodePayloadTooLarge,
+	http.StatusUnsupportedMediaType:  CodeUnsupportedMediaType,
+	http.StatusUnprocessableEntity:   CodeUnprocessableEntity,
+	http.StatusPreconditionRequired:  CodePreconditionRequired,
+	http.StatusTooManyRequests:       CodeTooManyRequests,
+	http.StatusInternalServerError:   CodeInternal,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// Problem is an RFC 7807 problem detail. Type is derived from Code, so
+// every code documents itself at the same URN.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Code      string `json:"code"`
+	Detail    string `json:"detail,omitempty"`
+	Field     string `json:"field,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func NewProblem(statusCode int, code string) Problem {
+	return Problem{
+		Type:   "urn:devbook:problem:" + code,
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Code:   code,
+	}
+}
+
+// CodeFor returns the generic code of an HTTP status.
+func CodeFor(statusCode int) string {
+	if code, ok := statusCodes[statusCode]; ok {
+		return code
+	}
+
+	if statusCode >= 500 {
+		return CodeInternal
+	}
+
+	return CodeBadRequest
+}
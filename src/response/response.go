@@ -2,26 +2,73 @@ package response
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"net/http"
 )
 
 func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	if data == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		return
+	}
+
+	// Encoding before writing the header leaves room to answer with an
+	// error instead of a truncated body.
+	body, err := json.Marshal(data)
+	if err != nil {
+		Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	Raw(w, statusCode, body)
+}
+
+// Raw answers with a body that is already encoded JSON.
+func Raw(w http.ResponseWriter, statusCode int, body []byte) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
+	w.Write(append(body, '\n'))
+}
+
+// Error answers with a problem whose code follows from statusCode.
+func Error(w http.ResponseWriter, statusCode int, err error) {
+	Fail(w, statusCode, CodeFor(statusCode), err)
+}
+
+// Fail answers with a problem carrying a specific code.
+func Fail(w http.ResponseWriter, statusCode int, code string, err error) {
+	WriteProblem(w, ProblemFor(w, statusCode, code, err))
+}
 
-	if data != nil {
-		if err := json.NewEncoder(w).Encode(data); err != nil {
-			log.Fatal(err)
+// ProblemFor builds the problem Fail would answer with, for callers that
+// add to it. The error is only shown to clients for 4xx statuses; server
+// errors are logged under the request ID, which the client gets to quote
+// instead.
+func ProblemFor(w http.ResponseWriter, statusCode int, code string, err error) Problem {
+	problem := NewProblem(statusCode, code)
+	problem.RequestID = w.Header().Get("X-Request-ID")
+
+	if statusCode >= 500 {
+		if err != nil {
+			slog.Error("request failed", "status", statusCode, "code", code, "error", err, "request_id", problem.RequestID)
 		}
+	} else if err != nil {
+		problem.Detail = err.Error()
 	}
 
+	return problem
 }
 
-func Error(w http.ResponseWriter, statusCode int, err error) {
-	JSON(w, statusCode, struct {
-		Error string `json:"error"`
-	}{
-		Error: err.Error(),
-	})
+func WriteProblem(w http.ResponseWriter, problem Problem) {
+	body, err := json.Marshal(problem)
+	if err != nil {
+		slog.Error("encoding problem", "error", err)
+		w.WriteHeader(problem.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	w.Write(append(body, '\n'))
 }
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"devbook/src/controllers"
+	"net/http"
+)
+
+var bookmarksRoutes = []Route{
+	{
+		URI:          "/posts/{postId}/bookmark",
+		Method:       http.MethodPost,
+		Function:     controllers.BookmarkPost,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/posts/{postId}/bookmark",
+		Method:       http.MethodDelete,
+		Function:     controllers.UnbookmarkPost,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/bookmarks",
+		Method:       http.MethodGet,
+		Function:     controllers.GetBookmarks,
+		AuthRequired: true,
+	},
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"devbook/src/controllers"
+	"net/http"
+)
+
+var mediaRoutes = []Route{
+	{
+		URI:          "/media",
+		Method:       http.MethodPost,
+		Function:     controllers.UploadMedia,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/media/{mediaId}",
+		Method:       http.MethodGet,
+		Function:     controllers.GetMedia,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/media/{mediaId}/content",
+		Method:       http.MethodGet,
+		Function:     controllers.GetMediaContent,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/media/{mediaId}/thumbnail",
+		Method:       http.MethodGet,
+		Function:     controllers.GetMediaThumbnail,
+		AuthRequired: true,
+	},
+}
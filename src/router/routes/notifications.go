@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"devbook/src/controllers"
+	"net/http"
+)
+
+var notificationsRoutes = []Route{
+	{
+		URI:          "/notifications",
+		Method:       http.MethodGet,
+		Function:     controllers.GetNotifications,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/notifications/read-all",
+		Method:       http.MethodPost,
+		Function:     controllers.ReadAllNotifications,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/notifications/settings",
+		Method:       http.MethodGet,
+		Function:     controllers.GetNotificationSettings,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/notifications/settings",
+		Method:       http.MethodPut,
+		Function:     controllers.UpdateNotificationSettings,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/notifications/{notificationId}/read",
+		Method:       http.MethodPost,
+		Function:     controllers.ReadNotification,
+		AuthRequired: true,
+	},
+}
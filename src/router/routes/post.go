@@ -6,6 +6,18 @@ import (
 )
 
 var postRoutes = []Route{
+	{
+		URI:          "/posts/drafts",
+		Method:       http.MethodGet,
+		Function:     controllers.GetDrafts,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/posts/scheduled",
+		Method:       http.MethodGet,
+		Function:     controllers.GetScheduledPosts,
+		AuthRequired: true,
+	},
 	{
 		URI:          "/posts",
 		Method:       http.MethodPost,
@@ -30,6 +42,12 @@ var postRoutes = []Route{
 		Function:     controllers.UpdatePost,
 		AuthRequired: true,
 	},
+	{
+		URI:          "/posts/{postId}",
+		Method:       http.MethodPatch,
+		Function:     controllers.PatchPost,
+		AuthRequired: true,
+	},
 	{
 		URI:          "/posts/{postId}",
 		Method:       http.MethodDelete,
@@ -42,6 +60,12 @@ var postRoutes = []Route{
 		Function:     controllers.GetPostsByUser,
 		AuthRequired: true,
 	},
+	{
+		URI:          "/users/{userId}/mentions",
+		Method:       http.MethodGet,
+		Function:     controllers.GetMentions,
+		AuthRequired: true,
+	},
 	{
 		URI:          "/posts/{postId}/like",
 		Method:       http.MethodPost,
@@ -54,4 +78,46 @@ var postRoutes = []Route{
 		Function:     controllers.Unlike,
 		AuthRequired: true,
 	},
+	{
+		URI:          "/posts/{postId}/revisions",
+		Method:       http.MethodGet,
+		Function:     controllers.GetPostRevisions,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/posts/{postId}/revisions/diff",
+		Method:       http.MethodGet,
+		Function:     controllers.GetPostRevisionDiff,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/posts/{postId}/repost",
+		Method:       http.MethodPost,
+		Function:     controllers.Repost,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/posts/{postId}/repost",
+		Method:       http.MethodDelete,
+		Function:     controllers.Unrepost,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/posts/{postId}/pin",
+		Method:       http.MethodPost,
+		Function:     controllers.PinPost,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/posts/{postId}/pin",
+		Method:       http.MethodDelete,
+		Function:     controllers.UnpinPost,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/posts/{postId}/poll/votes",
+		Method:       http.MethodPost,
+		Function:     controllers.VotePoll,
+		AuthRequired: true,
+	},
 }
@@ -2,6 +2,9 @@ package routes
 
 import (
 	"devbook/src/middlewares"
+	"devbook/src/response"
+	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -18,14 +21,28 @@ func Configure(r *mux.Router) *mux.Router {
 	routes := usersRoutes
 	routes = append(routes, loginRoute)
 	routes = append(routes, postRoutes...)
+	routes = append(routes, notificationsRoutes...)
+	routes = append(routes, streamRoutes...)
+	routes = append(routes, webhooksRoutes...)
+	routes = append(routes, mediaRoutes...)
+	routes = append(routes, bookmarksRoutes...)
+	routes = append(routes, trendingRoutes...)
+	routes = append(routes, suggestionsRoutes...)
 
 	for _, route := range routes {
 		if route.AuthRequired {
-			r.HandleFunc(route.URI, middlewares.Logger(middlewares.Auth(route.Function))).Methods(route.Method)
+			r.HandleFunc(route.URI, middlewares.Logger(middlewares.Recover(middlewares.Auth(route.Function)))).Methods(route.Method)
 		} else {
-			r.HandleFunc(route.URI, middlewares.Logger(route.Function)).Methods(route.Method)
+			r.HandleFunc(route.URI, middlewares.Logger(middlewares.Recover(route.Function))).Methods(route.Method)
 		}
 	}
 
+	r.NotFoundHandler = middlewares.Logger(func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, http.StatusNotFound, errors.New("no such endpoint"))
+	})
+	r.MethodNotAllowedHandler = middlewares.Logger(func(w http.ResponseWriter, r *http.Request) {
+		response.Error(w, http.StatusMethodNotAllowed, fmt.Errorf("%s is not allowed here", r.Method))
+	})
+
 	return r
 }
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"devbook/src/controllers"
+	"net/http"
+)
+
+var streamRoutes = []Route{
+	{
+		URI:          "/stream",
+		Method:       http.MethodGet,
+		Function:     controllers.Stream,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/stream/ws",
+		Method:       http.MethodGet,
+		Function:     controllers.StreamWebSocket,
+		AuthRequired: true,
+	},
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"devbook/src/controllers"
+	"net/http"
+)
+
+var suggestionsRoutes = []Route{
+	{
+		URI:          "/users/{userId}/suggestions",
+		Method:       http.MethodGet,
+		Function:     controllers.GetSuggestions,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/suggestions/{suggestedId}",
+		Method:       http.MethodDelete,
+		Function:     controllers.DismissSuggestion,
+		AuthRequired: true,
+	},
+}
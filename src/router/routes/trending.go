@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"devbook/src/controllers"
+	"net/http"
+)
+
+var trendingRoutes = []Route{
+	{
+		URI:          "/trending/posts",
+		Method:       http.MethodGet,
+		Function:     controllers.GetTrendingPosts,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/trending/tags",
+		Method:       http.MethodGet,
+		Function:     controllers.GetTrendingTags,
+		AuthRequired: true,
+	},
+}
@@ -6,6 +6,12 @@ import (
 )
 
 var usersRoutes = []Route{
+	{
+		URI:          "/users/relationships",
+		Method:       http.MethodGet,
+		Function:     controllers.GetRelationships,
+		AuthRequired: true,
+	},
 	{
 		URI:          "/users",
 		Method:       http.MethodPost,
@@ -30,6 +36,18 @@ var usersRoutes = []Route{
 		Function:     controllers.UpdateUser,
 		AuthRequired: true,
 	},
+	{
+		URI:          "/users/{userId}",
+		Method:       http.MethodPatch,
+		Function:     controllers.PatchUser,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/by-nick/{nick}",
+		Method:       http.MethodGet,
+		Function:     controllers.GetUserByNick,
+		AuthRequired: true,
+	},
 	{
 		URI:          "/users/{userId}",
 		Method:       http.MethodDelete,
@@ -66,4 +84,70 @@ var usersRoutes = []Route{
 		Function:     controllers.GetFollowing,
 		AuthRequired: true,
 	},
+	{
+		URI:          "/users/{userId}/follow-requests",
+		Method:       http.MethodGet,
+		Function:     controllers.GetFollowRequests,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/follow-requests/{requesterId}/approve",
+		Method:       http.MethodPost,
+		Function:     controllers.ApproveFollowRequest,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/follow-requests/{requesterId}/reject",
+		Method:       http.MethodPost,
+		Function:     controllers.RejectFollowRequest,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/block",
+		Method:       http.MethodPost,
+		Function:     controllers.BlockUser,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/block",
+		Method:       http.MethodDelete,
+		Function:     controllers.UnblockUser,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/mute",
+		Method:       http.MethodPost,
+		Function:     controllers.MuteUser,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/mute",
+		Method:       http.MethodDelete,
+		Function:     controllers.UnmuteUser,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/blocks",
+		Method:       http.MethodGet,
+		Function:     controllers.GetBlockedUsers,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/mutes",
+		Method:       http.MethodGet,
+		Function:     controllers.GetMutedUsers,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/relationship",
+		Method:       http.MethodGet,
+		Function:     controllers.GetRelationship,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/users/{userId}/mutuals",
+		Method:       http.MethodGet,
+		Function:     controllers.GetMutuals,
+		AuthRequired: true,
+	},
 }
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"devbook/src/controllers"
+	"net/http"
+)
+
+var webhooksRoutes = []Route{
+	{
+		URI:          "/webhooks",
+		Method:       http.MethodPost,
+		Function:     controllers.CreateWebhook,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/webhooks",
+		Method:       http.MethodGet,
+		Function:     controllers.GetWebhooks,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/webhooks/{webhookId}",
+		Method:       http.MethodGet,
+		Function:     controllers.GetWebhook,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/webhooks/{webhookId}",
+		Method:       http.MethodDelete,
+		Function:     controllers.DeleteWebhook,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/webhooks/{webhookId}/deliveries",
+		Method:       http.MethodGet,
+		Function:     controllers.GetWebhookDeliveries,
+		AuthRequired: true,
+	},
+	{
+		URI:          "/webhooks/{webhookId}/test",
+		Method:       http.MethodPost,
+		Function:     controllers.TestWebhook,
+		AuthRequired: true,
+	},
+}
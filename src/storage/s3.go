@@ -0,0 +1,161 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// s3 talks to any S3-compatible server (AWS, MinIO, ...) with path-style
+// requests signed with AWS Signature Version 4.
+type s3 struct {
+	endpoint  *url.URL
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+func NewS3(endpoint, region, bucket, accessKey, secretKey string) BlobStore {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		parsed = &url.URL{Scheme: "http", Host: endpoint}
+	}
+
+	return &s3{
+		endpoint:  parsed,
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (store s3) Put(key string, data []byte, contentType string) error {
+	response, err := store.do(http.MethodPut, key, data, contentType)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return expect(response, http.StatusOK)
+}
+
+func (store s3) Get(key string) (io.ReadCloser, error) {
+	response, err := store.do(http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, ErrNotExist
+	}
+
+	if err = expect(response, http.StatusOK); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+
+	return response.Body, nil
+}
+
+func (store s3) Delete(key string) error {
+	response, err := store.do(http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	return expect(response, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+func (store s3) do(method, key string, body []byte, contentType string) (*http.Response, error) {
+	target := *store.endpoint
+	target.Path = "/" + store.bucket + "/" + strings.TrimPrefix(key, "/")
+
+	request, err := http.NewRequest(method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+
+	store.sign(request, body, time.Now().UTC())
+
+	return store.client.Do(request)
+}
+
+func (store s3) sign(request *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		"host:" + request.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + store.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+store.secretKey), date)
+	key = hmacSHA256(key, store.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		store.accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func expect(response *http.Response, statusCodes ...int) error {
+	for _, statusCode := range statusCodes {
+		if response.StatusCode == statusCode {
+			return nil
+		}
+	}
+
+	message, _ := ioutil.ReadAll(io.LimitReader(response.Body, 512))
+	return fmt.Errorf("s3: unexpected status %d: %s", response.StatusCode, strings.TrimSpace(string(message)))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"devbook/src/config"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrNotExist = errors.New("blob does not exist")
+
+// BlobStore keeps uploaded files under opaque keys chosen by the caller.
+type BlobStore interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// Open returns the store selected by MEDIA_STORAGE.
+func Open() (BlobStore, error) {
+	switch config.MediaStorage {
+	case "local":
+		return NewLocal(config.MediaDir), nil
+	case "s3":
+		if config.S3Endpoint == "" || config.S3Bucket == "" {
+			return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required for s3 storage")
+		}
+
+		return NewS3(config.S3Endpoint, config.S3Region, config.S3Bucket, config.S3AccessKey, config.S3SecretKey), nil
+	default:
+		return nil, fmt.Errorf("unknown media storage %q", config.MediaStorage)
+	}
+}
@@ -0,0 +1,133 @@
+package suggestions
+
+import (
+	"database/sql"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"sort"
+	"time"
+)
+
+const (
+	// candidates is how many accounts each source proposes before ranking.
+	candidates = 100
+
+	// tagHorizon is how far back shared tags and activity are looked for.
+	tagHorizon = 90 * 24 * time.Hour
+
+	mutualWeight = 3.0
+	tagWeight    = 1.0
+	activeWeight = 0.5
+)
+
+// For ranks accounts userID may want to follow. Mutual connections count
+// the most, then shared tags; accounts that posted recently get a boost
+// that fades over a month.
+func For(db *sql.DB, userID uint64, limit int, now time.Time) ([]models.Suggestion, error) {
+	repository := repositories.Suggestions(db)
+	since := now.Add(-tagHorizon)
+
+	mutuals, err := repository.FindByMutuals(userID, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := repository.FindBySharedTags(userID, since, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := repository.FindActive(userID, now.Add(-7*24*time.Hour), candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint64]*models.Suggestion)
+	var userIDs []uint64
+	add := func(found []models.Suggestion, merge func(*models.Suggestion, models.Suggestion)) {
+		for _, suggestion := range found {
+			existing, ok := byID[suggestion.User.ID]
+			if !ok {
+				existing = &models.Suggestion{User: models.User{ID: suggestion.User.ID}}
+				byID[suggestion.User.ID] = existing
+				userIDs = append(userIDs, suggestion.User.ID)
+			}
+
+			merge(existing, suggestion)
+		}
+	}
+
+	add(mutuals, func(existing *models.Suggestion, found models.Suggestion) {
+		existing.Mutuals = found.Mutuals
+		existing.FollowedBy = found.FollowedBy
+	})
+	add(shared, func(existing *models.Suggestion, found models.Suggestion) {
+		existing.SharedTags = found.SharedTags
+	})
+	add(active, func(*models.Suggestion, models.Suggestion) {})
+
+	lastPosts, err := repository.FindLastPostTimes(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]models.Suggestion, 0, len(byID))
+	for _, userID := range userIDs {
+		suggestion := byID[userID]
+
+		if lastPostAt, ok := lastPosts[userID]; ok {
+			suggestion.LastPostAt = &lastPostAt
+		}
+
+		suggestion.Score = score(*suggestion, now)
+		suggestions = append(suggestions, *suggestion)
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].User.ID < suggestions[j].User.ID
+	})
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	chosen := make([]uint64, len(suggestions))
+	for i, suggestion := range suggestions {
+		chosen[i] = suggestion.User.ID
+	}
+
+	users, err := repositories.Users(db).FindByIDs(chosen, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	found := suggestions[:0]
+	for _, suggestion := range suggestions {
+		user, ok := users[suggestion.User.ID]
+		if !ok {
+			continue
+		}
+
+		suggestion.User = user
+		suggestion.Explain()
+		found = append(found, suggestion)
+	}
+
+	return found, nil
+}
+
+func score(suggestion models.Suggestion, now time.Time) float64 {
+	score := mutualWeight*float64(suggestion.Mutuals) + tagWeight*float64(len(suggestion.SharedTags))
+
+	if suggestion.LastPostAt != nil {
+		days := now.Sub(*suggestion.LastPostAt).Hours() / 24
+		if days < 30 {
+			score += activeWeight * (1 - days/30)
+		}
+	}
+
+	return score
+}
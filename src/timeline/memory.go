@@ -0,0 +1,121 @@
+package timeline
+
+import (
+	"sort"
+	"sync"
+)
+
+// Memory keeps timelines in the process. They are lost on restart and
+// rebuilt from the database on the next read.
+type Memory struct {
+	mutex     sync.Mutex
+	size      int
+	timelines map[uint64][]Entry
+}
+
+func NewMemory(size int) *Memory {
+	return &Memory{
+		size:      size,
+		timelines: make(map[uint64][]Entry),
+	}
+}
+
+func (store *Memory) Push(userID uint64, entries ...Entry) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	timeline, ok := store.timelines[userID]
+	if !ok {
+		return nil
+	}
+
+	store.timelines[userID] = store.merge(timeline, entries)
+	return nil
+}
+
+func (store *Memory) Replace(userID uint64, entries []Entry) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if len(entries) == 0 {
+		delete(store.timelines, userID)
+		return nil
+	}
+
+	store.timelines[userID] = store.merge(nil, entries)
+	return nil
+}
+
+func (store *Memory) Range(userID uint64, limit int) ([]Entry, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	timeline := store.timelines[userID]
+	if limit > len(timeline) {
+		limit = len(timeline)
+	}
+
+	entries := make([]Entry, limit)
+	copy(entries, timeline)
+	return entries, nil
+}
+
+func (store *Memory) Remove(userID uint64, entry Entry) error {
+	return store.filter(userID, func(kept Entry) bool {
+		return kept.PostID != entry.PostID
+	})
+}
+
+func (store *Memory) RemoveAuthor(userID, authorID uint64) error {
+	return store.filter(userID, func(kept Entry) bool {
+		return kept.AuthorID != authorID
+	})
+}
+
+func (store *Memory) filter(userID uint64, keep func(Entry) bool) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	timeline, ok := store.timelines[userID]
+	if !ok {
+		return nil
+	}
+
+	var kept []Entry
+	for _, entry := range timeline {
+		if keep(entry) {
+			kept = append(kept, entry)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(store.timelines, userID)
+	} else {
+		store.timelines[userID] = kept
+	}
+
+	return nil
+}
+
+// merge adds entries to timeline, newest first, dropping repeated posts and
+// everything past the size of the store.
+func (store *Memory) merge(timeline, entries []Entry) []Entry {
+	merged := make([]Entry, 0, len(timeline)+len(entries))
+	seen := make(map[uint64]bool, len(timeline)+len(entries))
+	for _, entry := range append(append([]Entry{}, entries...), timeline...) {
+		if !seen[entry.PostID] {
+			seen[entry.PostID] = true
+			merged = append(merged, entry)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].CreatedAt.After(merged[j].CreatedAt)
+	})
+
+	if len(merged) > store.size {
+		merged = merged[:store.size]
+	}
+
+	return merged
+}
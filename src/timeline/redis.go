@@ -0,0 +1,364 @@
+package timeline
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	redisTimeout = 5 * time.Second
+
+	// redisTTL drops the timelines of users who stopped reading them. They
+	// are rebuilt from the database if the user comes back.
+	redisTTL = 14 * 24 * time.Hour
+)
+
+// pushScript only adds to timelines that exist, so a push never leaves a
+// partial timeline where a rebuild is due.
+const pushScript = `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+for i = 3, #ARGV, 2 do
+	redis.call('ZADD', KEYS[1], ARGV[i], ARGV[i + 1])
+end
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -tonumber(ARGV[1]) - 1)
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return 1
+`
+
+var errProtocol = errors.New("redis: malformed reply")
+
+type redisError string
+
+func (err redisError) Error() string {
+	return "redis: " + string(err)
+}
+
+// Redis keeps each timeline in a sorted set scored by the creation time of
+// the post in milliseconds. Members are "postID:authorID", so an author's
+// posts can be dropped on unfollow without asking the database. It talks
+// RESP over a single connection and works with any server that speaks it
+// and runs Lua scripts.
+type Redis struct {
+	addr     string
+	password string
+	size     int
+
+	mutex  sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func NewRedis(addr, password string, size int) *Redis {
+	return &Redis{addr: addr, password: password, size: size}
+}
+
+func (store *Redis) Push(userID uint64, entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	command := []string{"EVAL", pushScript, "1", key(userID), strconv.Itoa(store.size), ttl()}
+	for _, entry := range entries {
+		command = append(command, score(entry), member(entry))
+	}
+
+	_, err := store.do(command)
+	return err
+}
+
+func (store *Redis) Replace(userID uint64, entries []Entry) error {
+	if len(entries) == 0 {
+		_, err := store.do([]string{"DEL", key(userID)})
+		return err
+	}
+
+	add := []string{"ZADD", key(userID)}
+	for _, entry := range entries {
+		add = append(add, score(entry), member(entry))
+	}
+
+	_, err := store.do(
+		[]string{"MULTI"},
+		[]string{"DEL", key(userID)},
+		add,
+		[]string{"ZREMRANGEBYRANK", key(userID), "0", strconv.Itoa(-store.size - 1)},
+		[]string{"EXPIRE", key(userID), ttl()},
+		[]string{"EXEC"},
+	)
+	return err
+}
+
+func (store *Redis) Range(userID uint64, limit int) ([]Entry, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	replies, err := store.do([]string{"ZREVRANGE", key(userID), "0", strconv.Itoa(limit - 1), "WITHSCORES"})
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := replies[0].([]interface{})
+	if !ok || len(items)%2 != 0 {
+		return nil, errProtocol
+	}
+
+	entries := make([]Entry, 0, len(items)/2)
+	for i := 0; i < len(items); i += 2 {
+		entry, err := parseEntry(items[i], items[i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func (store *Redis) Remove(userID uint64, entry Entry) error {
+	_, err := store.do([]string{"ZREM", key(userID), member(entry)})
+	return err
+}
+
+func (store *Redis) RemoveAuthor(userID, authorID uint64) error {
+	replies, err := store.do([]string{"ZRANGE", key(userID), "0", "-1"})
+	if err != nil {
+		return err
+	}
+
+	items, ok := replies[0].([]interface{})
+	if !ok {
+		return errProtocol
+	}
+
+	command := []string{"ZREM", key(userID)}
+	suffix := ":" + strconv.FormatUint(authorID, 10)
+	for _, item := range items {
+		if value, ok := item.(string); ok && strings.HasSuffix(value, suffix) {
+			command = append(command, value)
+		}
+	}
+
+	if len(command) == 2 {
+		return nil
+	}
+
+	_, err = store.do(command)
+	return err
+}
+
+// do sends the commands in one round trip and returns their replies. The
+// connection is dropped on any I/O error and dialled again by the next call.
+func (store *Redis) do(commands ...[]string) ([]interface{}, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.conn == nil {
+		if err := store.dial(); err != nil {
+			return nil, err
+		}
+	}
+
+	replies, err := store.roundTrip(commands)
+	if err != nil {
+		store.conn.Close()
+		store.conn = nil
+		return nil, err
+	}
+
+	for _, reply := range replies {
+		if err = replyError(reply); err != nil {
+			return nil, err
+		}
+	}
+
+	return replies, nil
+}
+
+func (store *Redis) dial() error {
+	conn, err := net.DialTimeout("tcp", store.addr, redisTimeout)
+	if err != nil {
+		return err
+	}
+
+	store.conn = conn
+	store.reader = bufio.NewReader(conn)
+
+	if store.password == "" {
+		return nil
+	}
+
+	replies, err := store.roundTrip([][]string{{"AUTH", store.password}})
+	if err == nil {
+		err = replyError(replies[0])
+	}
+	if err != nil {
+		conn.Close()
+		store.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+func (store *Redis) roundTrip(commands [][]string) ([]interface{}, error) {
+	if err := store.conn.SetDeadline(time.Now().Add(redisTimeout)); err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(store.conn)
+	for _, command := range commands {
+		fmt.Fprintf(writer, "*%d\r\n", len(command))
+		for _, arg := range command {
+			fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(arg), arg)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	replies := make([]interface{}, len(commands))
+	for i := range replies {
+		reply, err := store.read()
+		if err != nil {
+			return nil, err
+		}
+
+		replies[i] = reply
+	}
+
+	return replies, nil
+}
+
+// read parses one RESP reply. Error replies come back as redisError values
+// rather than errors, since they leave the connection usable.
+func (store *Redis) read() (interface{}, error) {
+	line, err := store.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errProtocol
+	}
+	body := line[1 : len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return body, nil
+	case '-':
+		return redisError(body), nil
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		length, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errProtocol
+		}
+		if length < 0 {
+			return nil, nil
+		}
+
+		data := make([]byte, length+2)
+		if _, err = io.ReadFull(store.reader, data); err != nil {
+			return nil, err
+		}
+
+		return string(data[:length]), nil
+	case '*':
+		length, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errProtocol
+		}
+		if length < 0 {
+			return nil, nil
+		}
+
+		items := make([]interface{}, length)
+		for i := range items {
+			if items[i], err = store.read(); err != nil {
+				return nil, err
+			}
+		}
+
+		return items, nil
+	default:
+		return nil, errProtocol
+	}
+}
+
+func replyError(reply interface{}) error {
+	switch value := reply.(type) {
+	case redisError:
+		return value
+	case []interface{}:
+		for _, item := range value {
+			if err := replyError(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func key(userID uint64) string {
+	return "timeline:" + strconv.FormatUint(userID, 10)
+}
+
+func member(entry Entry) string {
+	return fmt.Sprintf("%d:%d", entry.PostID, entry.AuthorID)
+}
+
+func score(entry Entry) string {
+	return strconv.FormatInt(entry.CreatedAt.UnixMilli(), 10)
+}
+
+func ttl() string {
+	return strconv.Itoa(int(redisTTL / time.Second))
+}
+
+func parseEntry(memberReply, scoreReply interface{}) (Entry, error) {
+	value, ok := memberReply.(string)
+	if !ok {
+		return Entry{}, errProtocol
+	}
+
+	postID, authorID, found := strings.Cut(value, ":")
+	if !found {
+		return Entry{}, errProtocol
+	}
+
+	var entry Entry
+	var err error
+	if entry.PostID, err = strconv.ParseUint(postID, 10, 64); err != nil {
+		return Entry{}, errProtocol
+	}
+	if entry.AuthorID, err = strconv.ParseUint(authorID, 10, 64); err != nil {
+		return Entry{}, errProtocol
+	}
+
+	scoreText, ok := scoreReply.(string)
+	if !ok {
+		return Entry{}, errProtocol
+	}
+
+	milliseconds, err := strconv.ParseFloat(scoreText, 64)
+	if err != nil {
+		return Entry{}, errProtocol
+	}
+	entry.CreatedAt = time.UnixMilli(int64(milliseconds))
+
+	return entry, nil
+}
@@ -0,0 +1,291 @@
+package timeline
+
+import (
+	"context"
+	"database/sql"
+	"devbook/src/config"
+	"devbook/src/database"
+	"devbook/src/events"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// Entry is a post pushed into someone's home timeline.
+type Entry struct {
+	PostID    uint64
+	AuthorID  uint64
+	CreatedAt time.Time
+}
+
+// Store keeps a precomputed home timeline per user, newest first and capped
+// at TIMELINE_SIZE entries. A missing timeline means it has to be rebuilt
+// from the database, so Push leaves those alone and only Replace creates
+// them.
+type Store interface {
+	Push(userID uint64, entries ...Entry) error
+	Replace(userID uint64, entries []Entry) error
+	Range(userID uint64, limit int) ([]Entry, error)
+	Remove(userID uint64, entry Entry) error
+	RemoveAuthor(userID, authorID uint64) error
+}
+
+// Open returns the store selected by TIMELINE_STORE.
+func Open() (Store, error) {
+	switch config.TimelineStore {
+	case "memory":
+		return NewMemory(config.TimelineSize), nil
+	case "redis":
+		return NewRedis(config.RedisAddr, config.RedisPassword, config.TimelineSize), nil
+	default:
+		return nil, fmt.Errorf("unknown timeline store %q", config.TimelineStore)
+	}
+}
+
+// current is the store the worker fans out to. Until Start runs, feeds are
+// built entirely at read time.
+var current Store
+
+// Start opens the timeline store and runs the worker that keeps it up to
+// date with published, deleted and (un)followed posts.
+func Start() error {
+	store, err := Open()
+	if err != nil {
+		return err
+	}
+
+	db, err := database.Connection()
+	if err != nil {
+		return err
+	}
+
+	current = store
+	go listen(db, store)
+	return nil
+}
+
+// Read returns the home feed candidates of userID, newest first. Posts of
+// authors with too many followers to fan out and reposts are merged in at
+// read time. If the store fails the whole feed is read from the database.
+func Read(ctx context.Context, db *sql.DB, userID uint64) ([]models.Post, error) {
+	repository := repositories.Posts(db)
+	if current == nil {
+		return repository.Find(userID)
+	}
+
+	entries, err := current.Range(userID, config.TimelineSize)
+	if err == nil && len(entries) == 0 {
+		entries, err = rebuild(db, current, userID)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "reading timeline, falling back to the database", "user_id", userID, "error", err)
+		return repository.Find(userID)
+	}
+
+	postIDs := make([]uint64, len(entries))
+	for i, entry := range entries {
+		postIDs[i] = entry.PostID
+	}
+
+	posts, err := repository.FindTimeline(userID, postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	popular, err := repository.FindByPopularAuthors(userID, config.FanoutMaxFollowers, config.TimelineSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reposts older than a full timeline would sort below all of it.
+	var since time.Time
+	if len(entries) >= config.TimelineSize {
+		since = entries[len(entries)-1].CreatedAt
+	}
+
+	reposts, err := repository.FindReposts(userID, since, config.TimelineSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return merge(reposts, popular, posts), nil
+}
+
+func rebuild(db *sql.DB, store Store, userID uint64) ([]Entry, error) {
+	posts, err := repositories.Posts(db).FindByFollowedAuthors(userID, config.TimelineSize)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, len(posts))
+	for i, post := range posts {
+		entries[i] = entryOf(post)
+	}
+
+	if err = store.Replace(userID, entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// listen resubscribes whenever the bus drops the worker for falling behind,
+// picking up the missed events from the replay buffer.
+func listen(db *sql.DB, store Store) {
+	var lastEventID uint64
+
+	for {
+		subscription, missed := events.SubscribeAll(lastEventID)
+
+		for _, event := range missed {
+			dispatch(db, store, event)
+			lastEventID = event.ID
+		}
+
+		for event := range subscription.Events() {
+			dispatch(db, store, event)
+			lastEventID = event.ID
+		}
+	}
+}
+
+func dispatch(db *sql.DB, store Store, event events.Event) {
+	var err error
+
+	switch data := event.Data.(type) {
+	case models.Post:
+		switch event.Type {
+		case events.PostCreated:
+			err = fanOut(db, store, data)
+		case events.PostDeleted:
+			err = retract(db, store, data)
+		}
+	case events.Follow:
+		switch event.Type {
+		case events.UserFollowed:
+			err = backfill(db, store, data)
+		case events.UserUnfollowed:
+			err = store.RemoveAuthor(data.FollowerID, data.UserID)
+		}
+	}
+
+	if err != nil {
+		slog.Error("updating timelines", "event", event.Type, "event_id", event.ID, "error", err)
+	}
+}
+
+// fanOut pushes a new post into the timelines of its author and followers.
+// Authors with more than FANOUT_MAX_FOLLOWERS followers are skipped; their
+// posts are read when the feed is built instead.
+func fanOut(db *sql.DB, store Store, post models.Post) error {
+	entry := entryOf(post)
+	if err := store.Push(post.AuthorID, entry); err != nil {
+		return err
+	}
+
+	if post.Visibility == models.VisibilityOnlyMe {
+		return nil
+	}
+
+	followerIDs, err := repositories.Users(db).FindFollowerIDs(post.AuthorID)
+	if err != nil {
+		return err
+	}
+
+	if len(followerIDs) > config.FanoutMaxFollowers {
+		return nil
+	}
+
+	for _, followerID := range followerIDs {
+		if err = store.Push(followerID, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// retract removes a deleted post from every timeline it was pushed to.
+func retract(db *sql.DB, store Store, post models.Post) error {
+	entry := entryOf(post)
+	if err := store.Remove(post.AuthorID, entry); err != nil {
+		return err
+	}
+
+	followerIDs, err := repositories.Users(db).FindFollowerIDs(post.AuthorID)
+	if err != nil {
+		return err
+	}
+
+	for _, followerID := range followerIDs {
+		if err = store.Remove(followerID, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// backfill gives a new follower the latest TIMELINE_SIZE posts of the
+// account they followed, unless its posts are read at request time anyway.
+func backfill(db *sql.DB, store Store, follow events.Follow) error {
+	followerIDs, err := repositories.Users(db).FindFollowerIDs(follow.UserID)
+	if err != nil {
+		return err
+	}
+
+	if len(followerIDs) > config.FanoutMaxFollowers {
+		return nil
+	}
+
+	posts, err := repositories.Posts(db).FindLatestByAuthor(follow.UserID, follow.FollowerID, config.TimelineSize)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]Entry, len(posts))
+	for i, post := range posts {
+		entries[i] = entryOf(post)
+	}
+
+	return store.Push(follow.FollowerID, entries...)
+}
+
+func entryOf(post models.Post) Entry {
+	return Entry{PostID: post.ID, AuthorID: post.AuthorID, CreatedAt: post.CreatedAt}
+}
+
+// merge combines feed sources into one list ordered by latest activity, a
+// post appearing once at its most recent appearance.
+func merge(sources ...[]models.Post) []models.Post {
+	var posts []models.Post
+	for _, source := range sources {
+		posts = append(posts, source...)
+	}
+
+	sort.SliceStable(posts, func(i, j int) bool {
+		return activity(posts[i]).After(activity(posts[j]))
+	})
+
+	seen := make(map[uint64]bool, len(posts))
+	merged := posts[:0]
+	for _, post := range posts {
+		if !seen[post.ID] {
+			seen[post.ID] = true
+			merged = append(merged, post)
+		}
+	}
+
+	return merged
+}
+
+func activity(post models.Post) time.Time {
+	if post.RepostedAt != nil {
+		return *post.RepostedAt
+	}
+
+	return post.CreatedAt
+}
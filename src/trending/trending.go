@@ -0,0 +1,253 @@
+package trending
+
+import (
+	"database/sql"
+	"devbook/src/config"
+	"devbook/src/database"
+	"devbook/src/models"
+	"devbook/src/repositories"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Limit is how many posts and tags each window keeps.
+	Limit = 50
+
+	// minUsers is how many different users have to interact with a post,
+	// or with posts carrying a tag, before it can trend.
+	minUsers = 3
+
+	// userBudget is how many posts a user can interact with in a window at
+	// full weight. Beyond it their weight is spread over all of them, so
+	// liking everything in sight does not push anything up.
+	userBudget = 20
+
+	likeWeight   = 1.0
+	repostWeight = 2.0
+)
+
+type Window struct {
+	Name   string
+	Length time.Duration
+}
+
+var Windows = []Window{
+	{Name: "1h", Length: time.Hour},
+	{Name: "24h", Length: 24 * time.Hour},
+	{Name: "7d", Length: 7 * 24 * time.Hour},
+}
+
+// FindWindow returns the window called name, 24h when name is empty.
+func FindWindow(name string) (Window, error) {
+	if name == "" {
+		name = "24h"
+	}
+
+	for _, window := range Windows {
+		if window.Name == name {
+			return window, nil
+		}
+	}
+
+	return Window{}, fmt.Errorf("unknown window %q, use 1h, 24h or 7d", name)
+}
+
+// Snapshot is what was trending in a window when it was last computed.
+// Posts only carry their ID; readers load them as the viewer may see them.
+type Snapshot struct {
+	Posts      []models.TrendingPost
+	Tags       []models.TrendingTag
+	ComputedAt time.Time
+}
+
+var (
+	mutex     sync.RWMutex
+	snapshots = make(map[string]Snapshot)
+)
+
+// Get returns the latest snapshot of window, empty until the first run of
+// the job finishes.
+func Get(window Window) Snapshot {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return snapshots[window.Name]
+}
+
+// Start runs the job that recomputes every window each TRENDING_INTERVAL.
+func Start() error {
+	db, err := database.Connection()
+	if err != nil {
+		return err
+	}
+
+	go refresh(db, config.TrendingInterval)
+	return nil
+}
+
+func refresh(db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, window := range Windows {
+			if err := update(db, window, time.Now()); err != nil {
+				slog.Error("computing trends", "window", window.Name, "error", err)
+			}
+		}
+
+		<-ticker.C
+	}
+}
+
+func update(db *sql.DB, window Window, now time.Time) error {
+	repository := repositories.Trends(db)
+
+	interactions, err := repository.FindInteractions(now.Add(-window.Length), now.Add(-config.TrendingMinAccountAge))
+	if err != nil {
+		return err
+	}
+
+	postIDs := make([]uint64, 0, len(interactions))
+	seen := make(map[uint64]bool)
+	for _, interaction := range interactions {
+		if !seen[interaction.PostID] {
+			seen[interaction.PostID] = true
+			postIDs = append(postIDs, interaction.PostID)
+		}
+	}
+
+	tags, err := repository.FindTags(postIDs)
+	if err != nil {
+		return err
+	}
+
+	snapshot := Compute(interactions, tags, window, now)
+
+	mutex.Lock()
+	snapshots[window.Name] = snapshot
+	mutex.Unlock()
+
+	return nil
+}
+
+// Compute scores posts and tags by how fast they are gathering
+// interactions: every like or repost in the window adds its weight, halved
+// for each quarter of the window that has passed since it happened. A user
+// counts once per tag, with their strongest interaction.
+func Compute(interactions []models.Interaction, tags map[uint64][]string, window Window, now time.Time) Snapshot {
+	halfLife := window.Length.Hours() / 4
+
+	touched := make(map[uint64]map[uint64]bool)
+	for _, interaction := range interactions {
+		if touched[interaction.UserID] == nil {
+			touched[interaction.UserID] = make(map[uint64]bool)
+		}
+		touched[interaction.UserID][interaction.PostID] = true
+	}
+
+	postScores := make(map[uint64]float64)
+	postUsers := make(map[uint64]map[uint64]bool)
+	tagUsers := make(map[string]map[uint64]float64)
+	tagPosts := make(map[string]map[uint64]bool)
+
+	for _, interaction := range interactions {
+		weight := likeWeight
+		if interaction.Kind == models.InteractionRepost {
+			weight = repostWeight
+		}
+
+		if posts := len(touched[interaction.UserID]); posts > userBudget {
+			weight *= float64(userBudget) / float64(posts)
+		}
+
+		age := now.Sub(interaction.CreatedAt).Hours()
+		if age < 0 {
+			age = 0
+		}
+		weight *= math.Pow(0.5, age/halfLife)
+
+		postScores[interaction.PostID] += weight
+		if postUsers[interaction.PostID] == nil {
+			postUsers[interaction.PostID] = make(map[uint64]bool)
+		}
+		postUsers[interaction.PostID][interaction.UserID] = true
+
+		for _, tag := range tags[interaction.PostID] {
+			if tagUsers[tag] == nil {
+				tagUsers[tag] = make(map[uint64]float64)
+				tagPosts[tag] = make(map[uint64]bool)
+			}
+
+			if weight > tagUsers[tag][interaction.UserID] {
+				tagUsers[tag][interaction.UserID] = weight
+			}
+			tagPosts[tag][interaction.PostID] = true
+		}
+	}
+
+	snapshot := Snapshot{ComputedAt: now}
+
+	for postID, score := range postScores {
+		if len(postUsers[postID]) < minUsers {
+			continue
+		}
+
+		snapshot.Posts = append(snapshot.Posts, models.TrendingPost{
+			Post:  models.Post{ID: postID},
+			Score: round(score),
+			Users: len(postUsers[postID]),
+		})
+	}
+
+	sort.Slice(snapshot.Posts, func(i, j int) bool {
+		if snapshot.Posts[i].Score != snapshot.Posts[j].Score {
+			return snapshot.Posts[i].Score > snapshot.Posts[j].Score
+		}
+		return snapshot.Posts[i].Post.ID > snapshot.Posts[j].Post.ID
+	})
+
+	if len(snapshot.Posts) > Limit {
+		snapshot.Posts = snapshot.Posts[:Limit]
+	}
+
+	for tag, users := range tagUsers {
+		if len(users) < minUsers {
+			continue
+		}
+
+		var score float64
+		for _, weight := range users {
+			score += weight
+		}
+
+		snapshot.Tags = append(snapshot.Tags, models.TrendingTag{
+			Tag:   tag,
+			Score: round(score),
+			Users: len(users),
+			Posts: len(tagPosts[tag]),
+		})
+	}
+
+	sort.Slice(snapshot.Tags, func(i, j int) bool {
+		if snapshot.Tags[i].Score != snapshot.Tags[j].Score {
+			return snapshot.Tags[i].Score > snapshot.Tags[j].Score
+		}
+		return snapshot.Tags[i].Tag < snapshot.Tags[j].Tag
+	})
+
+	if len(snapshot.Tags) > Limit {
+		snapshot.Tags = snapshot.Tags[:Limit]
+	}
+
+	return snapshot
+}
+
+func round(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
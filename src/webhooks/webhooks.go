@@ -0,0 +1,237 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"devbook/src/config"
+	"devbook/src/database"
+	"devbook/src/events"
+	"devbook/src/models"
+	"devbook/src/netguard"
+	"devbook/src/repositories"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+const TestEvent = "webhook.test"
+
+type Deliverer struct {
+	Client      *http.Client
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// NewDeliverer returns a deliverer whose client refuses to connect to
+// internal addresses, checked again on every dial and redirect.
+func NewDeliverer() Deliverer {
+	transport := &http.Transport{
+		DialContext:         netguard.Dialer(config.WebhookTimeout).DialContext,
+		TLSHandshakeTimeout: config.WebhookTimeout,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+
+	return Deliverer{
+		Client:      &http.Client{Timeout: config.WebhookTimeout, Transport: transport},
+		MaxAttempts: config.WebhookMaxAttempts,
+		Backoff:     time.Second,
+	}
+}
+
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func GenerateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+func Payload(event events.Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// Send makes a single delivery attempt and reports its outcome. It does not
+// touch the database, so it can be pointed at any receiver.
+func (deliverer Deliverer) Send(webhook models.Webhook, event events.Event, payload []byte, attempt int) models.WebhookDelivery {
+	delivery := models.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		Event:     event.Type,
+		Payload:   string(payload),
+		Attempt:   attempt,
+	}
+
+	request, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "devbook-webhooks")
+	request.Header.Set("X-Devbook-Event", event.Type)
+	request.Header.Set("X-Devbook-Delivery", fmt.Sprintf("%d-%d", webhook.ID, event.ID))
+	request.Header.Set("X-Devbook-Signature", Sign(webhook.Secret, payload))
+
+	resp, err := deliverer.Client.Do(request)
+	if err != nil {
+		delivery.Error = truncate(err.Error(), 500)
+		return delivery
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = resp.Status
+	}
+
+	return delivery
+}
+
+// Deliver retries with exponential backoff until the receiver accepts the
+// event or the attempts run out, logging every attempt.
+func (deliverer Deliverer) Deliver(db *sql.DB, webhook models.Webhook, event events.Event) {
+	repository := repositories.Webhooks(db)
+
+	payload, err := Payload(event)
+	if err != nil {
+		slog.Error("encoding webhook payload", "webhook_id", webhook.ID, "event_id", event.ID, "error", err)
+		return
+	}
+
+	for attempt := 1; attempt <= deliverer.MaxAttempts; attempt++ {
+		delivery := deliverer.Send(webhook, event, payload, attempt)
+		if _, err = repository.CreateDelivery(delivery); err != nil {
+			slog.Error("recording webhook delivery", "webhook_id", webhook.ID, "event_id", event.ID, "error", err)
+		}
+
+		if delivery.Success {
+			if webhook.Failures > 0 {
+				if err = repository.ResetFailures(webhook.ID); err != nil {
+					slog.Error("resetting webhook failures", "webhook_id", webhook.ID, "error", err)
+				}
+			}
+			return
+		}
+
+		if attempt < deliverer.MaxAttempts {
+			time.Sleep(deliverer.Backoff << (attempt - 1))
+		}
+	}
+
+	if err = repository.RecordFailure(webhook.ID, config.WebhookMaxFailures); err != nil {
+		slog.Error("recording webhook failure", "webhook_id", webhook.ID, "error", err)
+	}
+}
+
+type job struct {
+	webhook models.Webhook
+	event   events.Event
+}
+
+// Start runs WEBHOOK_WORKERS delivery workers fed by a queue of
+// WEBHOOK_QUEUE_SIZE jobs, so a burst of events cannot start an unbounded
+// number of deliveries.
+func Start() error {
+	db, err := database.Connection()
+	if err != nil {
+		return err
+	}
+
+	deliverer := NewDeliverer()
+	jobs := make(chan job, config.WebhookQueueSize)
+
+	for i := 0; i < config.WebhookWorkers; i++ {
+		go work(db, deliverer, jobs)
+	}
+
+	go listen(db, jobs)
+	return nil
+}
+
+func work(db *sql.DB, deliverer Deliverer, jobs <-chan job) {
+	for job := range jobs {
+		deliverer.Deliver(db, job.webhook, job.event)
+	}
+}
+
+// listen resubscribes whenever the bus drops the worker for falling behind,
+// picking up the missed events from the replay buffer.
+func listen(db *sql.DB, jobs chan<- job) {
+	var lastEventID uint64
+
+	for {
+		subscription, missed := events.SubscribeAll(lastEventID)
+
+		for _, event := range missed {
+			dispatch(db, jobs, event)
+			lastEventID = event.ID
+		}
+
+		for event := range subscription.Events() {
+			dispatch(db, jobs, event)
+			lastEventID = event.ID
+		}
+	}
+}
+
+// dispatch queues a delivery for every webhook subscribed to event. When
+// the queue is full the delivery is dropped and recorded as failed, so the
+// owner can see it in the delivery log.
+func dispatch(db *sql.DB, jobs chan<- job, event events.Event) {
+	repository := repositories.Webhooks(db)
+
+	webhooks, err := repository.FindActive(event.Type)
+	if err != nil {
+		slog.Error("finding webhooks", "event", event.Type, "event_id", event.ID, "error", err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Global && !event.RelevantTo(webhook.UserID) {
+			continue
+		}
+
+		select {
+		case jobs <- job{webhook, event}:
+			continue
+		default:
+		}
+
+		delivery := models.WebhookDelivery{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			Event:     event.Type,
+			Error:     "dropped: delivery queue is full",
+		}
+		if _, err = repository.CreateDelivery(delivery); err != nil {
+			slog.Error("recording webhook delivery", "webhook_id", webhook.ID, "event_id", event.ID, "error", err)
+		}
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) > max {
+		return s[:max]
+	}
+
+	return s
+}
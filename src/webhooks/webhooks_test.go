@@ -0,0 +1,110 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"devbook/src/config"
+	"devbook/src/events"
+	"devbook/src/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func receiver(t *testing.T, status int) (*httptest.Server, <-chan received) {
+	t.Helper()
+
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{r.Header.Clone(), body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, requests
+}
+
+func allowPrivate(t *testing.T, allow bool) {
+	t.Helper()
+
+	previous := config.WebhookAllowPrivate
+	config.WebhookAllowPrivate = allow
+	t.Cleanup(func() { config.WebhookAllowPrivate = previous })
+}
+
+func testEvent() events.Event {
+	return events.Event{ID: 7, Type: events.PostCreated, Data: map[string]int{"id": 1}, CreatedAt: time.Now()}
+}
+
+func TestSendSignsPayload(t *testing.T) {
+	allowPrivate(t, true)
+	server, requests := receiver(t, http.StatusNoContent)
+
+	webhook := models.Webhook{ID: 3, URL: server.URL, Secret: "s3cret"}
+	event := testEvent()
+	payload, err := Payload(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delivery := NewDeliverer().Send(webhook, event, payload, 1)
+	if !delivery.Success || delivery.StatusCode != http.StatusNoContent {
+		t.Fatalf("delivery = %+v, want success with 204", delivery)
+	}
+
+	got := <-requests
+	if string(got.body) != string(payload) {
+		t.Errorf("body = %s, want %s", got.body, payload)
+	}
+
+	signature := got.header.Get("X-Devbook-Signature")
+	if !hmac.Equal([]byte(signature), []byte(Sign(webhook.Secret, got.body))) {
+		t.Errorf("signature %q does not match the body", signature)
+	}
+	if Sign("other", got.body) == signature {
+		t.Error("signature does not depend on the secret")
+	}
+
+	for header, want := range map[string]string{
+		"Content-Type":       "application/json",
+		"X-Devbook-Event":    events.PostCreated,
+		"X-Devbook-Delivery": "3-7",
+	} {
+		if value := got.header.Get(header); value != want {
+			t.Errorf("%s = %q, want %q", header, value, want)
+		}
+	}
+}
+
+func TestSendReportsFailure(t *testing.T) {
+	allowPrivate(t, true)
+	server, _ := receiver(t, http.StatusInternalServerError)
+
+	delivery := NewDeliverer().Send(models.Webhook{URL: server.URL}, testEvent(), []byte("{}"), 2)
+	if delivery.Success || delivery.StatusCode != http.StatusInternalServerError || delivery.Attempt != 2 {
+		t.Errorf("delivery = %+v, want a failed second attempt with 500", delivery)
+	}
+}
+
+func TestSendRefusesInternalAddresses(t *testing.T) {
+	allowPrivate(t, false)
+	server, requests := receiver(t, http.StatusOK)
+
+	delivery := NewDeliverer().Send(models.Webhook{URL: server.URL}, testEvent(), []byte("{}"), 1)
+	if delivery.Success || delivery.StatusCode != 0 || delivery.Error == "" {
+		t.Errorf("delivery = %+v, want it refused before connecting", delivery)
+	}
+
+	select {
+	case <-requests:
+		t.Error("the receiver was reached")
+	default:
+	}
+}